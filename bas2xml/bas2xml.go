@@ -0,0 +1,86 @@
+package bas2xml
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ModeBAS 为高级弹幕（BAS）的弹幕模式
+const ModeBAS = 9
+
+// PoolSpecial 为高级弹幕所在的弹幕池
+const PoolSpecial = 2
+
+// Options 控制 <d> 元素的属性
+type Options struct {
+	SendTime  float64 // 时间轴起点（秒），各分块的发送时间为其加上分块的起点
+	Timestamp int64   // 发送时间戳（Unix 秒）
+	FontSize  int
+	Color     uint32
+	Pool      int
+	UserHash  string
+}
+
+// DefaultOptions 返回高级弹幕池、白色、25 号字的设置
+func DefaultOptions() Options {
+	return Options{FontSize: 25, Color: 0xFFFFFF, Pool: PoolSpecial, UserHash: "00000000"}
+}
+
+// Writer 将 BAS 分块写为哔哩哔哩弹幕 XML，每个分块一条 mode 9 的 <d>
+type Writer struct {
+	w      *bufio.Writer
+	opts   Options
+	chunks int
+	err    error
+}
+
+// NewWriter 写出 XML 头部并返回 Writer
+func NewWriter(w io.Writer, opts Options) *Writer {
+	x := &Writer{w: bufio.NewWriter(w), opts: opts}
+	x.printf("%s<i>\n", xml.Header)
+	x.printf("<chatserver>chat.bilibili.com</chatserver>\n<chatid>0</chatid>\n<mission>0</mission>\n")
+	x.printf("<maxlimit>0</maxlimit>\n<state>0</state>\n<real_name>0</real_name>\n<source>k-v</source>\n")
+	return x
+}
+
+func (x *Writer) printf(format string, args ...any) {
+	if x.err == nil {
+		_, x.err = fmt.Fprintf(x.w, format, args...)
+	}
+}
+
+// WriteChunk 写出一个分块的 BAS 代码，start 为分块相对 SendTime 的起点（秒）
+func (x *Writer) WriteChunk(code string, start float64) error {
+	x.chunks++
+	p := strconv.FormatFloat(x.opts.SendTime+start, 'f', 5, 64) + "," +
+		strconv.Itoa(ModeBAS) + "," +
+		strconv.Itoa(x.opts.FontSize) + "," +
+		strconv.FormatUint(uint64(x.opts.Color), 10) + "," +
+		strconv.FormatInt(x.opts.Timestamp, 10) + "," +
+		strconv.Itoa(x.opts.Pool) + "," +
+		x.opts.UserHash + "," +
+		strconv.Itoa(x.chunks)
+	x.printf(`<d p="%s">`, p)
+	if x.err == nil {
+		x.err = xml.EscapeText(x.w, []byte(code))
+	}
+	x.printf("</d>\n")
+	return x.err
+}
+
+// Chunks 返回已写出的分块数
+func (x *Writer) Chunks() int {
+	return x.chunks
+}
+
+// Close 写出结尾并刷新
+func (x *Writer) Close() error {
+	x.printf("</i>\n")
+	if x.err != nil {
+		return x.err
+	}
+	return x.w.Flush()
+}
@@ -0,0 +1,114 @@
+package basgen
+
+import (
+	"image/color"
+	"strconv"
+)
+
+// Value 表示属性值
+type Value interface {
+	appendValue(b []byte) []byte
+}
+
+// Num 表示数值，按最短形式输出
+type Num float64
+
+// Int 表示整数
+type Int int64
+
+// Str 表示带引号的字符串，输出时转义
+type Str string
+
+// Color 表示 0xRRGGBB 形式的颜色
+type Color uint32
+
+// Percent 表示百分比，如 100%
+type Percent float64
+
+// Ident 表示原样输出的标识符或关键字，如 true
+type Ident string
+
+func (v Num) appendValue(b []byte) []byte {
+	f := float64(v)
+	if f == 0 {
+		f = 0 // 去掉 -0
+	}
+	return strconv.AppendFloat(b, f, 'f', -1, 64)
+}
+
+func (v Int) appendValue(b []byte) []byte { return strconv.AppendInt(b, int64(v), 10) }
+
+func (v Str) appendValue(b []byte) []byte { return appendQuoted(b, string(v)) }
+
+func (v Color) appendValue(b []byte) []byte {
+	const hex = "0123456789ABCDEF"
+	b = append(b, '0', 'x')
+	for shift := 20; shift >= 0; shift -= 4 {
+		b = append(b, hex[(v>>shift)&0xF])
+	}
+	return b
+}
+
+func (v Percent) appendValue(b []byte) []byte { return append(Num(v).appendValue(b), '%') }
+
+func (v Ident) appendValue(b []byte) []byte { return append(b, v...) }
+
+// RGB 由 color.RGBA 构造 Color，忽略透明度
+func RGB(c color.RGBA) Color {
+	return Color(uint32(c.R)<<16 | uint32(c.G)<<8 | uint32(c.B))
+}
+
+// Prop 表示一个属性键值对
+type Prop struct {
+	Key   string
+	Value Value
+}
+
+// Props 为有序属性列表，按添加顺序输出
+type Props []Prop
+
+// Add 追加属性并返回新的列表，便于链式构造
+func (p Props) Add(key string, v Value) Props {
+	return append(p, Prop{Key: key, Value: v})
+}
+
+// Get 返回 key 对应的属性值
+func (p Props) Get(key string) (Value, bool) {
+	for _, prop := range p {
+		if prop.Key == key {
+			return prop.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Statement 表示一条 BAS 顶层语句
+type Statement interface {
+	appendStatement(b []byte) []byte
+}
+
+// Let 定义一个对象：let <Name> = <Kind>{<Props>}
+type Let struct {
+	Name  string
+	Kind  string // 对象类型，如 path、text
+	Props Props
+}
+
+// Set 表示一步属性动画：set <Target> {<Props>} <Duration>ms ["<Easing>"]
+type Set struct {
+	Target   string
+	Props    Props
+	Duration int64  // 毫秒
+	Easing   string // 缓动函数，为空时不输出
+}
+
+// Chain 表示顺序执行的动画链，第一步为 set，其余为 then set
+type Chain []Set
+
+// Group 将若干语句归为一组连续输出，如同一帧的全部对象
+type Group []Statement
+
+// Then 向动画链追加一步
+func (c Chain) Then(s Set) Chain {
+	return append(c, s)
+}
@@ -0,0 +1,59 @@
+package basgen
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+// 语句按 k=v 紧凑输出，数值取最短形式，字符串转义
+func TestFormat(t *testing.T) {
+	for _, tc := range []struct {
+		stmt Statement
+		want string
+	}{
+		{Let{Name: "a", Kind: "path", Props: Props{}.Add("d", Str("M0 0H1z")).Add("x", Percent(50)).Add("alpha", Num(0))},
+			"let a = path{d=\"M0 0H1z\" x=50% alpha=0}\n"},
+		{Let{Name: "t", Kind: "text", Props: Props{}.Add("content", Str("a \"b\"\\\n")).Add("bold", Ident("true"))},
+			"let t = text{content=\"a \\\"b\\\"\\\\\\n\" bold=true}\n"},
+		{Set{Target: "a", Props: Props{}.Add("fillColor", RGB(color.RGBA{R: 255, G: 8, B: 0xAB, A: 10})), Duration: 0},
+			"set a {fillColor=0xFF08AB} 0ms\n"},
+		{Chain{{Target: "a", Props: Props{}, Duration: 40}}.Then(Set{Target: "a", Props: Props{}.Add("alpha", Num(0.25)), Duration: 1001, Easing: "linear"}),
+			"set a {} 40ms\nthen set a {alpha=0.25} 1001ms \"linear\"\n"},
+		{Group{Set{Target: "a", Props: Props{}.Add("x", Num(math.Copysign(0, -1))), Duration: 5}, Set{Target: "b", Props: Props{}.Add("zIndex", Int(-3)), Duration: 0}},
+			"set a {x=0} 5ms\nset b {zIndex=-3} 0ms\n"},
+	} {
+		if got := Format(tc.stmt); got != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+	}
+}
+
+// 格式化结果可被 Parse 读回，再次格式化得到相同的文本
+func TestFormatParseRoundTrip(t *testing.T) {
+	src := Format(
+		Let{Name: "a", Kind: "path", Props: Props{}.
+			Add("d", Str("M0 0H10V10z")).Add("viewBox", Str("0 0 10 10")).
+			Add("width", Percent(100)).Add("anchorX", Num(0.5)).Add("fillColor", Color(0x00FF00)).
+			Add("borderWidth", Num(1.5)).Add("alpha", Num(0))},
+		Chain{
+			{Target: "a", Props: Props{}, Duration: 100},
+			{Target: "a", Props: Props{}.Add("alpha", Num(1)), Duration: 0},
+			{Target: "a", Props: Props{}.Add("d", Str("M0 0H5V5z")).Add("fillColor", Color(0xABCDEF)), Duration: 33, Easing: "linear"},
+		},
+	)
+	nodes, err := Parse(src)
+	if err != nil {
+		t.Fatalf("parse: %v\n%s", err, src)
+	}
+	stmts := make([]Statement, len(nodes))
+	for i, n := range nodes {
+		stmts[i] = n.Stmt
+	}
+	if got := Format(stmts...); got != src {
+		t.Errorf("round trip changed the code\n got %q\nwant %q", got, src)
+	}
+	if len(nodes) != 2 || nodes[1].Line != 2 || len(nodes[1].Lines) != 3 || nodes[1].Lines[2] != 4 {
+		t.Errorf("got nodes %+v, want a let on line 1 and a three-step chain on lines 2-4", nodes)
+	}
+}
@@ -0,0 +1,164 @@
+package basgen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const idChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// reserved 为不能用作对象名的关键字
+var reserved = map[string]bool{
+	"as": true, "do": true, "if": true, "in": true, "of": true, "to": true,
+	"def": true, "for": true, "let": true, "new": true, "set": true, "var": true, "NaN": true,
+	"else": true, "null": true, "path": true, "text": true, "then": true, "this": true, "true": true,
+	"false": true, "while": true, "button": true, "return": true,
+}
+
+// ShortID 返回第 n 个（从 0 开始）最短标识符：首字符为字母，其余为字母或数字
+func ShortID(n int) string {
+	first := n % 52
+	n /= 52
+	b := []byte{idChars[first]}
+	for n > 0 {
+		n--
+		b = append(b, idChars[n%62])
+		n /= 62
+	}
+	return string(b)
+}
+
+// IDEntry 记录短标识符对应的原始对象，用于调试
+type IDEntry struct {
+	ID    string
+	Frame int    // 对象首次出现的帧
+	Color string // 图层颜色 RRGGBB
+	Name  string // 未缩短时的名称
+}
+
+// IDAllocator 按使用顺序分配最短的唯一标识符，一次运行中所有分块共用同一个分配器
+type IDAllocator struct {
+	mu      sync.Mutex
+	n       int
+	record  bool
+	entries []IDEntry
+}
+
+// NewIDAllocator 创建分配器，record 为 true 时保留对应关系以便写出映射文件
+func NewIDAllocator(record bool) *IDAllocator {
+	return &IDAllocator{record: record}
+}
+
+// Next 为 e 描述的对象分配下一个标识符
+func (a *IDAllocator) Next(e IDEntry) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for {
+		id := ShortID(a.n)
+		a.n++
+		if reserved[id] {
+			continue
+		}
+		if a.record {
+			e.ID = id
+			a.entries = append(a.entries, e)
+		}
+		return id
+	}
+}
+
+// Skip 跳过接下来的 n 个标识符，之后分配的标识符与已分配 n 个对象后的长度相同，用于估算输出大小
+func (a *IDAllocator) Skip(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.n += max(n, 0)
+}
+
+// Mark 返回当前分配位置，配合 Rollback 撤销之后的分配
+func (a *IDAllocator) Mark() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.n
+}
+
+// Rollback 撤销 mark 之后分配的标识符，它们会被重新分配
+func (a *IDAllocator) Rollback(mark int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if mark >= a.n {
+		return
+	}
+	a.n = mark
+	for len(a.entries) > 0 {
+		last := a.entries[len(a.entries)-1]
+		if idIndex(last.ID) < mark {
+			break
+		}
+		a.entries = a.entries[:len(a.entries)-1]
+	}
+}
+
+// idIndex 为 ShortID 的逆运算
+func idIndex(id string) int {
+	n := 0
+	for i := len(id) - 1; i >= 1; i-- {
+		n = n*62 + indexOf(id[i]) + 1
+	}
+	return n*52 + indexOf(id[0])
+}
+
+func indexOf(c byte) int {
+	for i := 0; i < len(idChars); i++ {
+		if idChars[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// WriteMap 以制表符分隔写出 id、帧号、颜色与原始名称
+func (a *IDAllocator) WriteMap(w io.Writer) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "id\tframe\tcolor\tname")
+	for _, e := range a.entries {
+		fmt.Fprintf(bw, "%s\t%d\t%s\t%s\n", e.ID, e.Frame, e.Color, e.Name)
+	}
+	return bw.Flush()
+}
+
+// Rename 返回将对象名按 names 替换后的语句，未列出的名称保持不变
+func Rename(stmts []Statement, names map[string]string) []Statement {
+	rename := func(s string) string {
+		if n, ok := names[s]; ok {
+			return n
+		}
+		return s
+	}
+	out := make([]Statement, len(stmts))
+	for i, st := range stmts {
+		switch v := st.(type) {
+		case Let:
+			v.Name = rename(v.Name)
+			out[i] = v
+		case Set:
+			v.Target = rename(v.Target)
+			out[i] = v
+		case Chain:
+			c := make(Chain, len(v))
+			for j, s := range v {
+				s.Target = rename(s.Target)
+				c[j] = s
+			}
+			out[i] = c
+		case Group:
+			out[i] = Group(Rename(v, names))
+		default:
+			out[i] = st
+		}
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package basgen
+
+import (
+	"strings"
+	"testing"
+)
+
+// ShortID 先用完单字符再用两个字符，首字符总是字母；idIndex 是其逆运算
+func TestShortID(t *testing.T) {
+	for _, tc := range []struct {
+		n    int
+		want string
+	}{
+		{0, "a"}, {25, "z"}, {26, "A"}, {51, "Z"}, {52, "aa"}, {53, "ba"}, {52 * 63, "aaa"},
+	} {
+		if got := ShortID(tc.n); got != tc.want {
+			t.Errorf("ShortID(%d) = %q, want %q", tc.n, got, tc.want)
+		}
+	}
+	seen := make(map[string]bool)
+	for n := 0; n < 52*63*2; n++ {
+		id := ShortID(n)
+		if seen[id] {
+			t.Fatalf("ShortID(%d) = %q repeats", n, id)
+		}
+		seen[id] = true
+		if c := id[0]; !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			t.Fatalf("ShortID(%d) = %q starts with a digit", n, id)
+		}
+		if back := idIndex(id); back != n {
+			t.Fatalf("idIndex(%q) = %d, want %d", id, back, n)
+		}
+	}
+}
+
+// 分配器跳过关键字，且不会产生重复的标识符
+func TestIDAllocatorSkipsReserved(t *testing.T) {
+	a := NewIDAllocator(false)
+	seen := make(map[string]bool)
+	for i := 0; i < 52*63*2; i++ {
+		id := a.Next(IDEntry{})
+		if reserved[id] {
+			t.Fatalf("allocated reserved word %q", id)
+		}
+		if seen[id] {
+			t.Fatalf("allocated %q twice", id)
+		}
+		seen[id] = true
+	}
+	for _, w := range []string{"as", "do", "if", "in", "of", "to"} {
+		if seen[w] {
+			t.Errorf("allocated %q", w)
+		}
+	}
+}
+
+// Rollback 撤销标记之后的分配与映射记录，之后重新分配得到相同的标识符
+func TestIDAllocatorRollback(t *testing.T) {
+	a := NewIDAllocator(true)
+	for i := 0; i < 60; i++ {
+		a.Next(IDEntry{Frame: 0, Color: "FFFFFF", Name: "f0"})
+	}
+	mark := a.Mark()
+	first := []string{a.Next(IDEntry{Frame: 1, Name: "x"}), a.Next(IDEntry{Frame: 1, Name: "y"})}
+	a.Rollback(mark)
+	if a.Mark() != mark {
+		t.Fatalf("mark %d after rollback, want %d", a.Mark(), mark)
+	}
+	again := []string{a.Next(IDEntry{Frame: 2, Name: "x"}), a.Next(IDEntry{Frame: 2, Name: "y"})}
+	if first[0] != again[0] || first[1] != again[1] {
+		t.Errorf("reallocated %v, want %v", again, first)
+	}
+	// 回滚到更靠后的位置不产生影响
+	a.Rollback(a.Mark() + 10)
+
+	var sb strings.Builder
+	if err := a.WriteMap(&sb); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != 1+62 {
+		t.Fatalf("map has %d lines, want header and 62 entries", len(lines))
+	}
+	if want := again[0] + "\t2\t\tx"; lines[61] != want {
+		t.Errorf("map line %q, want %q", lines[61], want)
+	}
+	for _, l := range lines[1:] {
+		if strings.Contains(l, "\t1\t") {
+			t.Errorf("rolled back entry %q still in map", l)
+		}
+	}
+}
+
+// Skip 之后分配的标识符与依次分配相同数量后一致
+func TestIDAllocatorSkip(t *testing.T) {
+	a, b := NewIDAllocator(false), NewIDAllocator(false)
+	for i := 0; i < 4000; i++ {
+		a.Next(IDEntry{})
+	}
+	b.Skip(a.Mark())
+	b.Skip(-1)
+	if x, y := a.Next(IDEntry{}), b.Next(IDEntry{}); x != y || len(x) != 3 {
+		t.Errorf("got %q after skipping, want %q", y, x)
+	}
+}
@@ -0,0 +1,178 @@
+package basgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Diagnostic 表示一条检查结果，Line 为 0 时针对整个文件
+type Diagnostic struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return d.File + ": " + d.Msg
+	}
+	return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Msg)
+}
+
+// LintOptions 控制检查的限制，0 表示不检查
+type LintOptions struct {
+	MaxLine int // 单行最大字节数
+	MaxSize int // 单个文件最大字节数
+}
+
+// LintFile 表示一个待检查的分块文件
+type LintFile struct {
+	Name string
+	Data string
+}
+
+// Lint 检查一组分块文件：语法、跨分块重复的标识符、未定义或先用后定义的对象、
+// 负数时长、同一对象同一属性的动画在时间上重叠，以及行与文件尺寸
+func Lint(files []LintFile, opts LintOptions) []Diagnostic {
+	var diags []Diagnostic
+	defs := make(map[string]definition)
+	for _, f := range files {
+		own := lintFile(f, opts, defs)
+		sort.SliceStable(own, func(i, j int) bool { return own[i].Line < own[j].Line })
+		diags = append(diags, own...)
+	}
+	return diags
+}
+
+// definition 记录标识符首次定义的位置
+type definition struct {
+	file string
+	line int
+}
+
+func lintFile(f LintFile, opts LintOptions, defs map[string]definition) []Diagnostic {
+	var diags []Diagnostic
+	report := func(line int, format string, args ...any) {
+		diags = append(diags, Diagnostic{File: f.Name, Line: line, Msg: fmt.Sprintf(format, args...)})
+	}
+	if opts.MaxSize > 0 && len(f.Data) > opts.MaxSize {
+		report(0, "file size %d exceeds limit %d", len(f.Data), opts.MaxSize)
+	}
+	if opts.MaxLine > 0 {
+		for i, line := range strings.Split(f.Data, "\n") {
+			if len(line) > opts.MaxLine {
+				report(i+1, "line length %d exceeds limit %d", len(line), opts.MaxLine)
+			}
+		}
+	}
+
+	nodes, err := Parse(f.Data)
+	if err != nil {
+		if se, ok := err.(*SyntaxError); ok {
+			report(se.Line, "syntax error: %s (column %d)", se.Msg, se.Col)
+		} else {
+			report(0, "syntax error: %v", err)
+		}
+		return diags
+	}
+
+	// 本文件内的定义位置，用于检查先用后定义
+	local := make(map[string]int)
+	for _, n := range nodes {
+		if let, ok := n.Stmt.(Let); ok {
+			if prev, dup := defs[let.Name]; dup {
+				report(n.Line, "duplicate identifier %q (first defined at %s:%d)", let.Name, prev.file, prev.line)
+			} else {
+				defs[let.Name] = definition{f.Name, n.Line}
+			}
+			if _, seen := local[let.Name]; !seen {
+				local[let.Name] = n.Line
+			}
+		}
+	}
+
+	tl := make(timeline)
+	for _, n := range nodes {
+		chain, ok := n.Stmt.(Chain)
+		if !ok {
+			continue
+		}
+		var t int64
+		for i, s := range chain {
+			line := n.Lines[i]
+			if defLine, ok := local[s.Target]; !ok {
+				report(line, "undefined object %q", s.Target)
+			} else if defLine > line {
+				report(line, "object %q used before its definition at line %d", s.Target, defLine)
+			}
+			if s.Duration < 0 {
+				report(line, "negative duration %dms", s.Duration)
+			}
+			for _, p := range s.Props {
+				tl.add(s.Target, p, t, t+s.Duration, line)
+			}
+			if s.Duration > 0 {
+				t += s.Duration
+			}
+		}
+	}
+	for _, o := range tl.overlaps() {
+		report(o.line, "%s.%s animated at %dms-%dms overlaps line %d (%dms-%dms)",
+			o.target, o.key, o.start, o.end, o.other.line, o.other.start, o.other.end)
+	}
+	return diags
+}
+
+// step 表示某对象某属性在一段时间内的一次变化
+type step struct {
+	start, end int64
+	value      string
+	line       int
+}
+
+// timeline 按 "对象.属性" 收集动画步骤
+type timeline map[[2]string][]step
+
+func (tl timeline) add(target string, p Prop, start, end int64, line int) {
+	key := [2]string{target, p.Key}
+	tl[key] = append(tl[key], step{start: start, end: end, value: string(p.Value.appendValue(nil)), line: line})
+}
+
+type overlap struct {
+	target, key string
+	step
+	other step
+}
+
+// overlaps 找出同一属性上时间重叠的动画：有时长的变化区间相交，或同一时刻把属性设为不同的值
+func (tl timeline) overlaps() []overlap {
+	var out []overlap
+	keys := make([][2]string, 0, len(tl))
+	for k := range tl {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || (keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1])
+	})
+	for _, k := range keys {
+		steps := tl[k]
+		sort.SliceStable(steps, func(i, j int) bool { return steps[i].start < steps[j].start })
+		// 与此前结束最晚的一步比较
+		var last step
+		for i, s := range steps {
+			if i > 0 {
+				conflict := s.start < last.end ||
+					(s.start == last.start && s.end == last.end && s.value != last.value)
+				if conflict {
+					out = append(out, overlap{target: k[0], key: k[1], step: s, other: last})
+				}
+			}
+			if i == 0 || s.end > last.end || (s.end == last.end && s.start >= last.start) {
+				last = s
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].line < out[j].line })
+	return out
+}
@@ -0,0 +1,89 @@
+package basgen
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func lintMessages(files ...LintFile) []string {
+	var out []string
+	for _, d := range Lint(files, LintOptions{MaxLine: 40, MaxSize: 200}) {
+		out = append(out, d.String())
+	}
+	return out
+}
+
+// 跨分块重复的标识符、未定义或先用后定义的对象、负数时长与超长的行按位置报告
+func TestLint(t *testing.T) {
+	got := lintMessages(
+		LintFile{Name: "a_0.bas.txt", Data: "let a = path{d=\"M0 0H1z\"}\n" +
+			"set a {alpha=1} 0ms\n" +
+			"then set b {} 10ms\n" +
+			"set c {} 0ms\n" +
+			"let c = path{}\n"},
+		LintFile{Name: "a_1.bas.txt", Data: "let a = path{}\n" +
+			"set a {} -5ms\n" +
+			"let d = path{d=\"M0 0H100V100H0z\" x=50% y=10%}\n"},
+	)
+	want := []string{
+		`a_0.bas.txt:3: undefined object "b"`,
+		`a_0.bas.txt:4: object "c" used before its definition at line 5`,
+		`a_1.bas.txt:1: duplicate identifier "a" (first defined at a_0.bas.txt:1)`,
+		`a_1.bas.txt:2: negative duration -5ms`,
+		`a_1.bas.txt:3: line length 45 exceeds limit 40`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// 同一对象同一属性的动画在时间上重叠时报告，首尾相接的不算
+func TestLintOverlap(t *testing.T) {
+	got := lintMessages(LintFile{Name: "x", Data: "let a = path{}\n" +
+		"set a {alpha=1} 100ms\n" +
+		"then set a {alpha=0} 0ms\n" +
+		"set a {alpha=0.5} 50ms\n" +
+		"set a {x=1} 100ms\n"})
+	want := []string{`x:4: a.alpha animated at 0ms-50ms overlaps line 2 (0ms-100ms)`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// 语法错误报告行号后停止检查该文件，文件尺寸超限针对整个文件
+func TestLintSyntaxAndSize(t *testing.T) {
+	got := lintMessages(
+		LintFile{Name: "bad", Data: "let a = path{}\nset a {alpha=} 0ms\n"},
+		LintFile{Name: "big", Data: strings.Repeat("let a = path{}\n", 15)},
+	)
+	if len(got) < 2 || !strings.HasPrefix(got[0], "bad:2: syntax error:") || got[1] != "big: file size 225 exceeds limit 200" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// Parse 的错误带有行列位置
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		line int
+		msg  string
+	}{
+		{"then set a {} 0ms", 1, "then without a preceding set"},
+		{"let a = path{}\nthen set a {} 0ms", 2, "then without a preceding set"},
+		{"set a {} 10", 1, "duration 10 needs a unit"},
+		{"set a {c=0x1000000} 0ms", 1, "invalid color"},
+		{"let a = path{\n  x=1\n  y=}", 3, "expected value"},
+		{"foo", 1, "unexpected"},
+	} {
+		_, err := Parse(tc.src)
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("%q: got %v, want a syntax error", tc.src, err)
+			continue
+		}
+		if se.Line != tc.line || !strings.Contains(se.Msg, tc.msg) {
+			t.Errorf("%q: got %v, want line %d %q", tc.src, err, tc.line, tc.msg)
+		}
+	}
+}
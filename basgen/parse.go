@@ -0,0 +1,406 @@
+package basgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Node 表示解析出的一条语句及其位置
+type Node struct {
+	Line  int       // 语句起始行，从 1 开始
+	Stmt  Statement // Let 或 Chain
+	Lines []int     // Chain 中每一步所在的行
+}
+
+// SyntaxError 表示解析错误
+type SyntaxError struct {
+	Line, Col int
+	Msg       string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+}
+
+// Parse 解析 BAS 代码，支持 let 定义与 set / then set 动画链，属性之间可用空白、换行或逗号分隔
+func Parse(src string) ([]Node, error) {
+	p := &basParser{lx: lexer{src: src, line: 1, col: 1}}
+	p.next()
+	var nodes []Node
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.isIdent("let"):
+			line := p.tok.line
+			let, err := p.let()
+			if err != nil {
+				return nodes, err
+			}
+			nodes = append(nodes, Node{Line: line, Stmt: let})
+		case p.isIdent("set"):
+			line := p.tok.line
+			p.next()
+			step, err := p.step()
+			if err != nil {
+				return nodes, err
+			}
+			nodes = append(nodes, Node{Line: line, Stmt: Chain{step}, Lines: []int{line}})
+		case p.isIdent("then"):
+			line := p.tok.line
+			var last *Node
+			if len(nodes) > 0 {
+				last = &nodes[len(nodes)-1]
+			}
+			if last == nil {
+				return nodes, p.errorf("then without a preceding set")
+			}
+			chain, ok := last.Stmt.(Chain)
+			if !ok {
+				return nodes, p.errorf("then without a preceding set")
+			}
+			p.next()
+			if !p.isIdent("set") {
+				return nodes, p.errorf("expected set after then, found %s", p.tok)
+			}
+			p.next()
+			step, err := p.step()
+			if err != nil {
+				return nodes, err
+			}
+			last.Stmt = append(chain, step)
+			last.Lines = append(last.Lines, line)
+		default:
+			return nodes, p.errorf("unexpected %s", p.tok)
+		}
+	}
+	if p.lx.err != nil {
+		return nodes, p.lx.err
+	}
+	return nodes, nil
+}
+
+type basParser struct {
+	lx  lexer
+	tok token
+}
+
+func (p *basParser) next() {
+	p.tok = p.lx.next()
+}
+
+func (p *basParser) isIdent(s string) bool {
+	return p.tok.kind == tokIdent && p.tok.text == s
+}
+
+func (p *basParser) errorf(format string, args ...any) error {
+	if p.lx.err != nil {
+		return p.lx.err
+	}
+	return &SyntaxError{Line: p.tok.line, Col: p.tok.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *basParser) expect(kind tokenKind, what string) (token, error) {
+	t := p.tok
+	if t.kind != kind {
+		return t, p.errorf("expected %s, found %s", what, t)
+	}
+	p.next()
+	return t, nil
+}
+
+// let 解析 let <name> = <kind>{...}
+func (p *basParser) let() (Let, error) {
+	p.next()
+	name, err := p.expect(tokIdent, "identifier")
+	if err != nil {
+		return Let{}, err
+	}
+	if _, err := p.expect(tokAssign, "'='"); err != nil {
+		return Let{}, err
+	}
+	kind, err := p.expect(tokIdent, "object type")
+	if err != nil {
+		return Let{}, err
+	}
+	props, err := p.props()
+	if err != nil {
+		return Let{}, err
+	}
+	return Let{Name: name.text, Kind: kind.text, Props: props}, nil
+}
+
+// step 解析 <target> {...} <duration> ["easing"]
+func (p *basParser) step() (Set, error) {
+	target, err := p.expect(tokIdent, "identifier")
+	if err != nil {
+		return Set{}, err
+	}
+	props, err := p.props()
+	if err != nil {
+		return Set{}, err
+	}
+	dur, err := p.expect(tokNumber, "duration")
+	if err != nil {
+		return Set{}, err
+	}
+	var scale float64
+	switch dur.unit {
+	case "ms":
+		scale = 1
+	case "s":
+		scale = 1000
+	default:
+		return Set{}, &SyntaxError{Line: dur.line, Col: dur.col, Msg: fmt.Sprintf("duration %s needs a unit (ms or s)", dur.text)}
+	}
+	s := Set{Target: target.text, Props: props, Duration: int64(dur.num * scale)}
+	if p.tok.kind == tokString {
+		s.Easing = p.tok.text
+		p.next()
+	}
+	return s, nil
+}
+
+func (p *basParser) props() (Props, error) {
+	if _, err := p.expect(tokLBrace, "'{'"); err != nil {
+		return nil, err
+	}
+	props := Props{}
+	for p.tok.kind != tokRBrace {
+		if p.tok.kind == tokComma {
+			p.next()
+			continue
+		}
+		key, err := p.expect(tokIdent, "property name or '}'")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokAssign, "'='"); err != nil {
+			return nil, err
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		props = props.Add(key.text, v)
+	}
+	p.next()
+	return props, nil
+}
+
+func (p *basParser) value() (Value, error) {
+	t := p.tok
+	switch t.kind {
+	case tokString:
+		p.next()
+		return Str(t.text), nil
+	case tokColor:
+		p.next()
+		v, err := strconv.ParseUint(t.text, 16, 32)
+		if err != nil || v > 0xFFFFFF {
+			return nil, &SyntaxError{Line: t.line, Col: t.col, Msg: fmt.Sprintf("invalid color 0x%s", t.text)}
+		}
+		return Color(v), nil
+	case tokNumber:
+		p.next()
+		switch t.unit {
+		case "":
+			return Num(t.num), nil
+		case "%":
+			return Percent(t.num), nil
+		}
+		return nil, &SyntaxError{Line: t.line, Col: t.col, Msg: fmt.Sprintf("unexpected unit in value %s", t.text)}
+	case tokIdent:
+		p.next()
+		return Ident(t.text), nil
+	}
+	return nil, p.errorf("expected value, found %s", t)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokColor
+	tokAssign
+	tokLBrace
+	tokRBrace
+	tokComma
+)
+
+type token struct {
+	kind      tokenKind
+	text      string // 标识符、字符串内容（已反转义）、数字原文或颜色的十六进制部分
+	num       float64
+	unit      string // 数字后缀：%、ms、s
+	line, col int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of file"
+	case tokString:
+		return strconv.Quote(t.text)
+	case tokColor:
+		return "0x" + t.text
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+type lexer struct {
+	src       string
+	i         int
+	line, col int
+	err       error
+}
+
+func (l *lexer) peek(off int) byte {
+	if l.i+off < len(l.src) {
+		return l.src[l.i+off]
+	}
+	return 0
+}
+
+func (l *lexer) advance() {
+	if l.src[l.i] == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
+	l.i++
+}
+
+func (l *lexer) fail(line, col int, format string, args ...any) token {
+	if l.err == nil {
+		l.err = &SyntaxError{Line: line, Col: col, Msg: fmt.Sprintf(format, args...)}
+	}
+	l.i = len(l.src)
+	return token{kind: tokEOF, line: line, col: col}
+}
+
+func (l *lexer) next() token {
+	// 跳过空白与 // 注释
+	for l.i < len(l.src) {
+		c := l.src[l.i]
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			l.advance()
+		} else if c == '/' && l.peek(1) == '/' {
+			for l.i < len(l.src) && l.src[l.i] != '\n' {
+				l.advance()
+			}
+		} else {
+			break
+		}
+	}
+	t := token{line: l.line, col: l.col}
+	if l.i >= len(l.src) {
+		return t
+	}
+	start := l.i
+	switch c := l.src[l.i]; {
+	case c == '=':
+		l.advance()
+		t.kind, t.text = tokAssign, "="
+	case c == '{':
+		l.advance()
+		t.kind, t.text = tokLBrace, "{"
+	case c == '}':
+		l.advance()
+		t.kind, t.text = tokRBrace, "}"
+	case c == ',':
+		l.advance()
+		t.kind, t.text = tokComma, ","
+	case c == '"':
+		l.advance()
+		var sb strings.Builder
+		for {
+			if l.i >= len(l.src) || l.src[l.i] == '\n' {
+				return l.fail(t.line, t.col, "unterminated string")
+			}
+			ch := l.src[l.i]
+			l.advance()
+			if ch == '"' {
+				break
+			}
+			if ch == '\\' {
+				if l.i >= len(l.src) {
+					return l.fail(t.line, t.col, "unterminated string")
+				}
+				esc := l.src[l.i]
+				l.advance()
+				switch esc {
+				case 'n':
+					ch = '\n'
+				case 't':
+					ch = '\t'
+				case 'r':
+					ch = '\r'
+				case '"', '\\':
+					ch = esc
+				default:
+					return l.fail(l.line, l.col-2, "invalid escape \\%c", esc)
+				}
+			}
+			sb.WriteByte(ch)
+		}
+		t.kind, t.text = tokString, sb.String()
+	case c == '0' && (l.peek(1) == 'x' || l.peek(1) == 'X'):
+		l.advance()
+		l.advance()
+		for l.i < len(l.src) && isHex(l.src[l.i]) {
+			l.advance()
+		}
+		t.kind, t.text = tokColor, l.src[start+2:l.i]
+		if t.text == "" {
+			return l.fail(t.line, t.col, "invalid color literal")
+		}
+	case isDigit(c) || ((c == '-' || c == '+' || c == '.') && (isDigit(l.peek(1)) || l.peek(1) == '.')):
+		if c == '-' || c == '+' {
+			l.advance()
+		}
+		for l.i < len(l.src) && (isDigit(l.src[l.i]) || l.src[l.i] == '.') {
+			l.advance()
+		}
+		if l.i < len(l.src) && (l.src[l.i] == 'e' || l.src[l.i] == 'E') && (isDigit(l.peek(1)) || ((l.peek(1) == '-' || l.peek(1) == '+') && isDigit(l.peek(2)))) {
+			l.advance()
+			l.advance()
+			for l.i < len(l.src) && isDigit(l.src[l.i]) {
+				l.advance()
+			}
+		}
+		t.text = l.src[start:l.i]
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return l.fail(t.line, t.col, "invalid number %q", t.text)
+		}
+		t.kind, t.num = tokNumber, v
+		unitStart := l.i
+		if l.i < len(l.src) && l.src[l.i] == '%' {
+			l.advance()
+		} else {
+			for l.i < len(l.src) && isLetter(l.src[l.i]) {
+				l.advance()
+			}
+		}
+		t.unit = l.src[unitStart:l.i]
+		if t.unit != "" && t.unit != "%" && t.unit != "ms" && t.unit != "s" {
+			return l.fail(t.line, t.col, "unknown unit %q", t.unit)
+		}
+	case isLetter(c):
+		for l.i < len(l.src) && (isLetter(l.src[l.i]) || isDigit(l.src[l.i])) {
+			l.advance()
+		}
+		t.kind, t.text = tokIdent, l.src[start:l.i]
+	default:
+		return l.fail(t.line, t.col, "unexpected character %q", c)
+	}
+	return t
+}
+
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+func isLetter(c byte) bool { return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isHex(c byte) bool    { return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') }
@@ -0,0 +1,120 @@
+package basgen
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+const startComment = "// start "
+
+// ChunkStart 读取代码第一行 "// start <毫秒>ms" 注释标明的分块起点，分块内的时间均相对该起点；没有时返回 0
+func ChunkStart(code string) int64 {
+	line, _, _ := strings.Cut(code, "\n")
+	v, ok := strings.CutPrefix(strings.TrimSpace(line), startComment)
+	if !ok {
+		return 0
+	}
+	ms, err := strconv.ParseInt(strings.TrimSuffix(v, "ms"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return ms
+}
+
+// Format 将语句格式化为 BAS 代码，每条语句独占一行，属性按 k=v 紧凑输出
+func Format(stmts ...Statement) string {
+	return string(Append(nil, stmts...))
+}
+
+// Append 将格式化结果追加到 b
+func Append(b []byte, stmts ...Statement) []byte {
+	for _, s := range stmts {
+		b = s.appendStatement(b)
+	}
+	return b
+}
+
+// Write 将格式化结果写入 w
+func Write(w io.Writer, stmts ...Statement) error {
+	_, err := w.Write(Append(nil, stmts...))
+	return err
+}
+
+func (l Let) appendStatement(b []byte) []byte {
+	b = append(b, "let "...)
+	b = append(b, l.Name...)
+	b = append(b, " = "...)
+	b = append(b, l.Kind...)
+	b = appendProps(b, l.Props)
+	return append(b, '\n')
+}
+
+func (s Set) appendStatement(b []byte) []byte {
+	b = s.appendStep(append(b, "set "...))
+	return append(b, '\n')
+}
+
+func (s Set) appendStep(b []byte) []byte {
+	b = append(b, s.Target...)
+	b = append(b, ' ')
+	b = appendProps(b, s.Props)
+	b = append(b, ' ')
+	b = Int(s.Duration).appendValue(b)
+	b = append(b, "ms"...)
+	if s.Easing != "" {
+		b = append(b, ' ')
+		b = appendQuoted(b, s.Easing)
+	}
+	return b
+}
+
+func (c Chain) appendStatement(b []byte) []byte {
+	for i, s := range c {
+		if i == 0 {
+			b = append(b, "set "...)
+		} else {
+			b = append(b, "then set "...)
+		}
+		b = s.appendStep(b)
+		b = append(b, '\n')
+	}
+	return b
+}
+
+func (g Group) appendStatement(b []byte) []byte {
+	return Append(b, g...)
+}
+
+func appendProps(b []byte, props Props) []byte {
+	b = append(b, '{')
+	for i, p := range props {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = append(b, p.Key...)
+		b = append(b, '=')
+		b = p.Value.appendValue(b)
+	}
+	return append(b, '}')
+}
+
+// appendQuoted 输出双引号字符串，转义引号、反斜杠与换行
+func appendQuoted(b []byte, s string) []byte {
+	b = append(b, '"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\':
+			b = append(b, '\\', c)
+		case '\n':
+			b = append(b, '\\', 'n')
+		case '\r':
+			b = append(b, '\\', 'r')
+		case '\t':
+			b = append(b, '\\', 't')
+		default:
+			b = append(b, c)
+		}
+	}
+	return append(b, '"')
+}
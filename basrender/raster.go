@@ -0,0 +1,229 @@
+package basrender
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	v2btypes "video2bas/type"
+)
+
+// subSamples 为每行像素的纵向采样数
+const subSamples = 4
+
+type edge struct {
+	x0, y0, x1, y1 float64 // y0 < y1
+	dir            int
+}
+
+// fillPolygons 以非零环绕规则光栅化多边形，返回每个像素的覆盖率（0-1）
+func fillPolygons(w, h int, polys [][]v2btypes.Point) []float32 {
+	cov := make([]float32, w*h)
+	var edges []edge
+	for _, poly := range polys {
+		for i := range poly {
+			p, q := poly[i], poly[(i+1)%len(poly)]
+			if p.Y == q.Y {
+				continue
+			}
+			e := edge{x0: p.X, y0: p.Y, x1: q.X, y1: q.Y, dir: 1}
+			if p.Y > q.Y {
+				e = edge{x0: q.X, y0: q.Y, x1: p.X, y1: p.Y, dir: -1}
+			}
+			edges = append(edges, e)
+		}
+	}
+	if len(edges) == 0 {
+		return cov
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].y0 < edges[j].y0 })
+
+	type crossing struct {
+		x   float64
+		dir int
+	}
+	var active []edge
+	var xs []crossing
+	next := 0
+	weight := float32(1.0 / subSamples)
+	for py := 0; py < h; py++ {
+		row := cov[py*w : (py+1)*w]
+		for k := 0; k < subSamples; k++ {
+			sy := float64(py) + (float64(k)+0.5)/subSamples
+			for next < len(edges) && edges[next].y0 <= sy {
+				active = append(active, edges[next])
+				next++
+			}
+			xs = xs[:0]
+			kept := active[:0]
+			for _, e := range active {
+				if e.y1 <= sy {
+					continue
+				}
+				kept = append(kept, e)
+				if e.y0 <= sy {
+					t := (sy - e.y0) / (e.y1 - e.y0)
+					xs = append(xs, crossing{x: e.x0 + t*(e.x1-e.x0), dir: e.dir})
+				}
+			}
+			active = kept
+			sort.Slice(xs, func(i, j int) bool { return xs[i].x < xs[j].x })
+			winding, start := 0, 0.0
+			for _, c := range xs {
+				if winding == 0 {
+					start = c.x
+				}
+				winding += c.dir
+				if winding == 0 {
+					addSpan(row, start, c.x, weight)
+				}
+			}
+		}
+	}
+	for i, c := range cov {
+		if c > 1 {
+			cov[i] = 1
+		}
+	}
+	return cov
+}
+
+// addSpan 将 [xa, xb) 区间按像素内的横向占比累加到 row
+func addSpan(row []float32, xa, xb float64, weight float32) {
+	w := len(row)
+	xa = math.Max(0, xa)
+	xb = math.Min(float64(w), xb)
+	if xb <= xa {
+		return
+	}
+	ia, ib := int(xa), int(xb)
+	if ia == ib {
+		row[ia] += float32(xb-xa) * weight
+		return
+	}
+	row[ia] += float32(float64(ia+1)-xa) * weight
+	for i := ia + 1; i < ib && i < w; i++ {
+		row[i] += weight
+	}
+	if ib < w {
+		row[ib] += float32(xb-float64(ib)) * weight
+	}
+}
+
+// strokePolygons 将折线描边展开为线段四边形与圆形连接点，所有多边形方向一致以便非零规则求并集
+func strokePolygons(lines [][]v2btypes.Point, closed []bool, width float64) [][]v2btypes.Point {
+	r := width / 2
+	if r <= 0 {
+		return nil
+	}
+	var polys [][]v2btypes.Point
+	for li, pts := range lines {
+		n := len(pts)
+		segs := n - 1
+		if closed[li] {
+			segs = n
+		}
+		for i := 0; i < segs; i++ {
+			p, q := pts[i], pts[(i+1)%n]
+			dx, dy := q.X-p.X, q.Y-p.Y
+			l := math.Hypot(dx, dy)
+			if l == 0 {
+				continue
+			}
+			nx, ny := -dy/l*r, dx/l*r
+			polys = append(polys, orient([]v2btypes.Point{
+				{X: p.X + nx, Y: p.Y + ny}, {X: q.X + nx, Y: q.Y + ny},
+				{X: q.X - nx, Y: q.Y - ny}, {X: p.X - nx, Y: p.Y - ny},
+			}))
+		}
+		for _, p := range pts {
+			polys = append(polys, disk(p, r))
+		}
+	}
+	return polys
+}
+
+func disk(c v2btypes.Point, r float64) []v2btypes.Point {
+	const n = 12
+	pts := make([]v2btypes.Point, n)
+	for i := range pts {
+		a := 2 * math.Pi * float64(i) / n
+		pts[i] = v2btypes.Point{X: c.X + r*math.Cos(a), Y: c.Y + r*math.Sin(a)}
+	}
+	return pts
+}
+
+// orient 使多边形为正向（y 轴向下时顺时针）
+func orient(poly []v2btypes.Point) []v2btypes.Point {
+	a := 0.0
+	for i := range poly {
+		p, q := poly[i], poly[(i+1)%len(poly)]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	if a < 0 {
+		for i, j := 0, len(poly)-1; i < j; i, j = i+1, j-1 {
+			poly[i], poly[j] = poly[j], poly[i]
+		}
+	}
+	return poly
+}
+
+// composite 按覆盖率与不透明度将颜色 c 混合到 img 上
+func composite(img *image.RGBA, cov []float32, c color.RGBA, alpha float64) {
+	if alpha <= 0 {
+		return
+	}
+	for i, v := range cov {
+		if v == 0 {
+			continue
+		}
+		a := float64(v) * alpha
+		if a > 1 {
+			a = 1
+		}
+		p := img.Pix[i*4 : i*4+4]
+		p[0] = uint8(float64(p[0])*(1-a) + float64(c.R)*a + 0.5)
+		p[1] = uint8(float64(p[1])*(1-a) + float64(c.G)*a + 0.5)
+		p[2] = uint8(float64(p[2])*(1-a) + float64(c.B)*a + 0.5)
+		p[3] = uint8(float64(p[3])*(1-a) + 255*a + 0.5)
+	}
+}
+
+// flatten 将路径展开为折线，tol 为目标线段长度（像素）
+func flatten(p v2btypes.Path, tol float64) ([][]v2btypes.Point, []bool) {
+	var lines [][]v2btypes.Point
+	var closed []bool
+	for _, sp := range p.SubPaths {
+		if len(sp.Segments) == 0 {
+			continue
+		}
+		pts := []v2btypes.Point{sp.Start}
+		cur := sp.Start
+		for _, seg := range sp.Segments {
+			if seg.Kind == v2btypes.SegCubic {
+				c1, c2, end := seg.Pts[0], seg.Pts[1], seg.Pts[2]
+				l := dist(cur, c1) + dist(c1, c2) + dist(c2, end)
+				n := int(math.Min(64, math.Max(1, math.Ceil(l/tol))))
+				for k := 1; k <= n; k++ {
+					t := float64(k) / float64(n)
+					mt := 1 - t
+					a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+					pts = append(pts, v2btypes.Point{
+						X: a*cur.X + b*c1.X + c*c2.X + d*end.X,
+						Y: a*cur.Y + b*c1.Y + c*c2.Y + d*end.Y,
+					})
+				}
+			} else {
+				pts = append(pts, seg.Pts[0])
+			}
+			cur = seg.End()
+		}
+		lines = append(lines, pts)
+		closed = append(closed, sp.Closed)
+	}
+	return lines, closed
+}
+
+func dist(a, b v2btypes.Point) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
@@ -0,0 +1,327 @@
+package basrender
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"video2bas/basgen"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+// Options 控制渲染画布
+type Options struct {
+	Width, Height int
+	Background    color.RGBA
+}
+
+// DefaultOptions 返回 640x360 黑色背景
+func DefaultOptions() Options {
+	return Options{Width: 640, Height: 360, Background: color.RGBA{A: 255}}
+}
+
+// step 表示对象上的一步动画，start/end 为绝对时间（毫秒）
+type step struct {
+	start, end int64
+	props      basgen.Props
+	easing     string
+	order      int
+}
+
+type object struct {
+	name  string
+	kind  string
+	props basgen.Props
+	steps []step
+	order int
+}
+
+// Scene 汇总一个或多个分块中的对象与动画，可渲染任意时刻的画面
+type Scene struct {
+	objects []*object
+	end     int64
+	steps   int
+	paths   map[string]v2btypes.Path
+}
+
+// NewScene 创建空场景
+func NewScene() *Scene {
+	return &Scene{paths: make(map[string]v2btypes.Path)}
+}
+
+// Add 加入一个分块解析出的语句，分块从 at（毫秒）开始播放，标识符仅在分块内可见
+func (s *Scene) Add(chunk string, nodes []basgen.Node, at int64) error {
+	local := make(map[string]*object)
+	for _, n := range nodes {
+		switch st := n.Stmt.(type) {
+		case basgen.Let:
+			o := &object{name: st.Name, kind: st.Kind, props: append(basgen.Props{}, st.Props...), order: len(s.objects)}
+			local[st.Name] = o
+			s.objects = append(s.objects, o)
+		case basgen.Chain:
+			t := at
+			for i, set := range st {
+				o, ok := local[set.Target]
+				if !ok {
+					return fmt.Errorf("%s:%d: undefined object %q", chunk, n.Lines[i], set.Target)
+				}
+				end := t + max(set.Duration, 0)
+				if len(set.Props) > 0 {
+					o.steps = append(o.steps, step{start: t, end: end, props: set.Props, easing: set.Easing, order: s.steps})
+					s.steps++
+				}
+				t = end
+				s.end = max(s.end, t)
+			}
+		}
+	}
+	for _, o := range local {
+		sort.SliceStable(o.steps, func(i, j int) bool { return o.steps[i].start < o.steps[j].start })
+	}
+	return nil
+}
+
+// Duration 返回最后一步动画结束的时间
+func (s *Scene) Duration() int64 {
+	return s.end
+}
+
+// Render 渲染时刻 t（毫秒）的画面
+func (s *Scene) Render(t int64, opts Options) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = opts.Background.R, opts.Background.G, opts.Background.B, opts.Background.A
+	}
+
+	type drawable struct {
+		o     *object
+		props map[string]basgen.Value
+		z     float64
+	}
+	var list []drawable
+	for _, o := range s.objects {
+		if o.kind != "path" {
+			continue
+		}
+		props := o.stateAt(t)
+		if number(props["alpha"], 1) <= 0 {
+			continue
+		}
+		list = append(list, drawable{o: o, props: props, z: number(props["zIndex"], 0)})
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].z < list[j].z })
+	for _, d := range list {
+		s.draw(img, d.props, opts)
+	}
+	return img
+}
+
+// stateAt 计算对象在时刻 t 的属性：已结束的动画取目标值，进行中的动画按缓动插值
+func (o *object) stateAt(t int64) map[string]basgen.Value {
+	props := make(map[string]basgen.Value, len(o.props))
+	for _, p := range o.props {
+		props[p.Key] = p.Value
+	}
+	for _, st := range o.steps {
+		if st.start > t {
+			break
+		}
+		if t >= st.end {
+			for _, p := range st.props {
+				props[p.Key] = p.Value
+			}
+			continue
+		}
+		f := ease(st.easing, float64(t-st.start)/float64(st.end-st.start))
+		for _, p := range st.props {
+			props[p.Key] = interpolate(props[p.Key], p.Value, f)
+		}
+	}
+	return props
+}
+
+func ease(name string, f float64) float64 {
+	switch name {
+	case "ease-in":
+		return f * f
+	case "ease-out":
+		return f * (2 - f)
+	case "ease-in-out":
+		if f < 0.5 {
+			return 2 * f * f
+		}
+		return -1 + (4-2*f)*f
+	}
+	return f
+}
+
+// interpolate 在 from 与 to 之间插值：数值与颜色逐分量插值，结构相同的路径逐点插值，其余在结束时切换
+func interpolate(from, to basgen.Value, f float64) basgen.Value {
+	switch b := to.(type) {
+	case basgen.Num:
+		if a, ok := from.(basgen.Num); ok {
+			return a + basgen.Num(f)*(b-a)
+		}
+	case basgen.Percent:
+		if a, ok := from.(basgen.Percent); ok {
+			return a + basgen.Percent(f)*(b-a)
+		}
+	case basgen.Color:
+		if a, ok := from.(basgen.Color); ok {
+			var out basgen.Color
+			for shift := 0; shift <= 16; shift += 8 {
+				ca, cb := float64((a>>shift)&0xFF), float64((b>>shift)&0xFF)
+				out |= basgen.Color(math.Round(ca+f*(cb-ca))) << shift
+			}
+			return out
+		}
+	case basgen.Str:
+		if a, ok := from.(basgen.Str); ok {
+			if d, ok := interpolatePath(string(a), string(b), f); ok {
+				return basgen.Str(d)
+			}
+		}
+	}
+	if from == nil {
+		return to
+	}
+	return from
+}
+
+func interpolatePath(a, b string, f float64) (string, bool) {
+	pa, err1 := pathdata.Parse(a)
+	pb, err2 := pathdata.Parse(b)
+	if err1 != nil || err2 != nil || len(pa.SubPaths) != len(pb.SubPaths) {
+		return "", false
+	}
+	lerp := func(p, q v2btypes.Point) v2btypes.Point {
+		return v2btypes.Point{X: p.X + f*(q.X-p.X), Y: p.Y + f*(q.Y-p.Y)}
+	}
+	out := v2btypes.Path{SubPaths: make([]v2btypes.SubPath, len(pa.SubPaths))}
+	for i, sa := range pa.SubPaths {
+		sb := pb.SubPaths[i]
+		if len(sa.Segments) != len(sb.Segments) {
+			return "", false
+		}
+		sp := v2btypes.SubPath{Start: lerp(sa.Start, sb.Start), Closed: sa.Closed, Segments: make([]v2btypes.Segment, len(sa.Segments))}
+		for j, seg := range sa.Segments {
+			if seg.Kind != sb.Segments[j].Kind {
+				return "", false
+			}
+			ns := v2btypes.Segment{Kind: seg.Kind}
+			for k := range ns.Pts {
+				ns.Pts[k] = lerp(seg.Pts[k], sb.Segments[j].Pts[k])
+			}
+			sp.Segments[j] = ns
+		}
+		out.SubPaths[i] = sp
+	}
+	return out.SVG(-1), true
+}
+
+// number 返回数值属性，缺省或类型不符时返回 def
+func number(v basgen.Value, def float64) float64 {
+	switch n := v.(type) {
+	case basgen.Num:
+		return float64(n)
+	case basgen.Percent:
+		return float64(n) / 100
+	}
+	return def
+}
+
+// length 返回位置或尺寸，百分比相对于 base
+func length(v basgen.Value, base, def float64) float64 {
+	switch n := v.(type) {
+	case basgen.Num:
+		return float64(n)
+	case basgen.Percent:
+		return float64(n) / 100 * base
+	}
+	return def
+}
+
+func colorOf(v basgen.Value, def color.RGBA) color.RGBA {
+	if c, ok := v.(basgen.Color); ok {
+		return color.RGBA{R: uint8(c >> 16), G: uint8(c >> 8), B: uint8(c), A: 255}
+	}
+	return def
+}
+
+// parseViewBox 解析 "minX minY width height"
+func parseViewBox(v basgen.Value) (v2btypes.ViewBox, bool) {
+	s, ok := v.(basgen.Str)
+	if !ok {
+		return v2btypes.ViewBox{}, false
+	}
+	f := strings.FieldsFunc(string(s), func(r rune) bool { return r == ' ' || r == ',' })
+	if len(f) != 4 {
+		return v2btypes.ViewBox{}, false
+	}
+	var n [4]float64
+	for i, x := range f {
+		var err error
+		if n[i], err = strconv.ParseFloat(x, 64); err != nil {
+			return v2btypes.ViewBox{}, false
+		}
+	}
+	vb := v2btypes.ViewBox{X: n[0], Y: n[1], W: n[2], H: n[3]}
+	return vb, vb.W > 0 && vb.H > 0
+}
+
+func (s *Scene) path(d string) (v2btypes.Path, bool) {
+	if p, ok := s.paths[d]; ok {
+		return p, true
+	}
+	p, err := pathdata.Parse(d)
+	if err != nil {
+		return p, false
+	}
+	s.paths[d] = p
+	return p, true
+}
+
+// draw 将 path 对象按 viewBox 映射到其在画布上的位置后绘制填充与描边
+func (s *Scene) draw(img *image.RGBA, props map[string]basgen.Value, opts Options) {
+	d, _ := props["d"].(basgen.Str)
+	path, ok := s.path(string(d))
+	if !ok || path.Empty() {
+		return
+	}
+	W, H := float64(opts.Width), float64(opts.Height)
+	vb, ok := parseViewBox(props["viewBox"])
+	if !ok {
+		b := path.BBox()
+		vb = v2btypes.ViewBox{W: math.Max(b.MaxX, 1), H: math.Max(b.MaxY, 1)}
+	}
+	// 只给出宽或高时按 viewBox 比例计算另一边
+	w := length(props["width"], W, -1)
+	h := length(props["height"], H, -1)
+	switch {
+	case w < 0 && h < 0:
+		w, h = vb.W, vb.H
+	case h < 0:
+		h = w * vb.H / vb.W
+	case w < 0:
+		w = h * vb.W / vb.H
+	}
+	x := length(props["x"], W, 0) - number(props["anchorX"], 0)*w
+	y := length(props["y"], H, 0) - number(props["anchorY"], 0)*h
+	sx, sy := w/vb.W, h/vb.H
+	mapped := path.Transform(func(p v2btypes.Point) v2btypes.Point {
+		return v2btypes.Point{X: x + (p.X-vb.X)*sx, Y: y + (p.Y-vb.Y)*sy}
+	})
+
+	alpha := number(props["alpha"], 1)
+	fill := colorOf(props["fillColor"], color.RGBA{A: 255})
+	lines, closed := flatten(mapped, 1)
+	composite(img, fillPolygons(opts.Width, opts.Height, lines), fill, alpha)
+	if bw := number(props["borderWidth"], 0) * (sx + sy) / 2; bw > 0 {
+		border := colorOf(props["borderColor"], fill)
+		composite(img, fillPolygons(opts.Width, opts.Height, strokePolygons(lines, closed, bw)), border, alpha)
+	}
+}
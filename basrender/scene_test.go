@@ -0,0 +1,122 @@
+package basrender
+
+import (
+	"flag"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"video2bas/basgen"
+	"video2bas/json2bas"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+var update = flag.Bool("update", false, "重新生成 testdata 中的参考图")
+
+// testScene 用 pool 策略与补间生成两帧：白色背景、移动并变色的三角形以及半透明的圆
+func testScene(t *testing.T) *Scene {
+	t.Helper()
+	vb := v2btypes.ViewBox{W: 640, H: 480}
+	frame := func(i int, tri, fill string) v2btypes.FrameData {
+		return v2btypes.FrameData{FrameIndex: i, ViewBox: vb, Layers: []v2btypes.Layer{
+			{Color: "FFFFFF", PathData: "M0 0H640V480H0z", Z: 0, Alpha: 1},
+			{Color: fill, PathData: tri, Z: 1, Alpha: 1},
+			{Color: "2050E0", PathData: "M320 140C408 140 480 212 480 300 480 388 408 460 320 460 232 460 160 388 160 300 160 212 232 140 320 140z", Z: 2, Alpha: 0.5},
+		}}
+	}
+	gen, err := json2bas.NewGenerator(json2bas.StrategyPool, json2bas.Options{
+		ViewBox: vb,
+		Rate:    v2btypes.Rate{Num: 10, Den: 1},
+		Path:    pathdata.DefaultOptions(),
+		Tween:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var code strings.Builder
+	for _, fd := range []v2btypes.FrameData{
+		frame(0, "M40 40H280L160 240z", "E02020"),
+		frame(1, "M360 40H600L480 240z", "20A020"),
+	} {
+		text, _ := gen.Frame(fd)
+		code.WriteString(text)
+	}
+	code.WriteString(gen.Tail())
+	nodes, err := basgen.Parse(code.String())
+	if err != nil {
+		t.Fatalf("parse: %v\n%s", err, code.String())
+	}
+	scene := NewScene()
+	if err := scene.Add("test", nodes, 0); err != nil {
+		t.Fatal(err)
+	}
+	return scene
+}
+
+// 渲染生成的 BAS 并与 testdata 中的参考图逐像素比较，允许浮点误差带来的细微差别
+func TestRenderGolden(t *testing.T) {
+	scene := testScene(t)
+	opts := Options{Width: 64, Height: 48}
+	for _, tt := range []struct {
+		name string
+		at   int64
+	}{
+		{"frame0", 0},
+		{"tween", 50},
+		{"frame1", 150},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scene.Render(tt.at, opts)
+			path := filepath.Join("testdata", tt.name+".png")
+			if *update {
+				f, err := os.Create(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+				if err := png.Encode(f, got); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			want, err := png.Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want.Bounds() != got.Bounds() {
+				t.Fatalf("size %v, want %v", got.Bounds(), want.Bounds())
+			}
+			diff := 0
+			for y := 0; y < opts.Height; y++ {
+				for x := 0; x < opts.Width; x++ {
+					if !near(got.RGBAAt(x, y), want.At(x, y)) {
+						diff++
+					}
+				}
+			}
+			if diff > 0 {
+				t.Errorf("%d pixels differ from %s (run with -update to regenerate)", diff, path)
+			}
+		})
+	}
+}
+
+// near 判断两个颜色各通道相差不超过 2（8 位）
+func near(got, want color.Color) bool {
+	r1, g1, b1, a1 := got.RGBA()
+	r2, g2, b2, a2 := want.RGBA()
+	for _, d := range []int64{int64(r1) - int64(r2), int64(g1) - int64(g2), int64(b1) - int64(b2), int64(a1) - int64(a2)} {
+		if d > 2*257 || d < -2*257 {
+			return false
+		}
+	}
+	return true
+}
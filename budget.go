@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"video2bas/budget"
+	"video2bas/json2bas"
+	v2btypes "video2bas/type"
+	"video2bas/video2color"
+)
+
+// budgetOptions 为 -budget / -chunks 模式的参数
+type budgetOptions struct {
+	Bytes     int64
+	Chunks    int
+	MinFPS    v2btypes.Rate
+	MinColors int
+	Samples   int
+}
+
+// fitBudget 从视频中均匀抽取样本帧估算输出大小，自动选择精度、描摹参数、颜色数与帧率，
+// 使输出放入预算，并将选中的参数写回 opts
+func fitBudget(ctx context.Context, opts *pipelineOptions, b budgetOptions) {
+	duration, err := video2color.ProbeDuration(opts.VideoPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	samples, err := budgetSamples(ctx, opts, b, duration)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	base := budget.Settings{Colors: opts.ColorCount, FPS: opts.FPS, Trace: opts.Trace, Precision: opts.Path.Precision}
+	result, tried, err := budget.Fit(samples, base, budget.Options{
+		Budget:    b.Bytes,
+		Chunks:    b.Chunks,
+		ChunkSize: opts.MaxFileSize,
+		Duration:  duration,
+		MinFPS:    b.MinFPS,
+		MinColors: b.MinColors,
+		Parallel:  opts.Parallel,
+		Split:     opts.Split,
+		Strategy:  opts.Strategy,
+		Generator: opts.generatorOptions(),
+	})
+	for _, r := range tried {
+		log.Printf("Budget: %s -> %.0f bytes/frame x %d frames = %d bytes, %d chunks", r.Settings, r.BytesPerFrame, r.Frames, r.Estimate, r.Chunks)
+	}
+	if errors.Is(err, budget.ErrUnreachable) {
+		log.Fatalf("Budget: %v; lower -minfps or -mincolors, or raise the budget", err)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Budget: chose %s (estimated %d bytes in %d chunks)", result.Settings, result.Estimate, result.Chunks)
+	opts.ColorCount = result.Colors
+	opts.FPS = result.FPS
+	opts.Trace = result.Trace
+	opts.Path.Precision = result.Precision
+}
+
+// budgetRun 为状态相关的输出（对象池、增量、补间）每段连续抽取的帧数
+const budgetRun = 8
+
+// budgetSamples 抽取约 Samples 帧样本。逐帧独立生成时在全片均匀抽取单帧；
+// 输出依赖上一帧时改为在全片均匀分布的若干段中，按 -fps 抽取连续的帧
+func budgetSamples(ctx context.Context, opts *pipelineOptions, b budgetOptions, duration float64) ([][]v2btypes.Frame, error) {
+	if opts.Strategy != json2bas.StrategyPool && !opts.Delta && !opts.Tween {
+		// 以每秒 1000*Samples/毫秒时长 的帧率抽样，得到约 Samples 帧
+		sampleRate := v2btypes.NewRate(int64(b.Samples)*1000, max(1, int64(math.Round(duration*1000))))
+		log.Printf("Budget: sampling %d frames from %.1fs of video...", b.Samples, duration)
+		frames, err := video2color.ExtractFrames(ctx, opts.VideoPath, sampleRate, opts.MaxWidth)
+		if err != nil {
+			return nil, err
+		}
+		runs := make([][]v2btypes.Frame, len(frames))
+		for i, f := range frames {
+			runs[i] = []v2btypes.Frame{f}
+		}
+		return runs, nil
+	}
+	n := min(b.Samples, budgetRun)
+	count := (b.Samples + n - 1) / n
+	length := float64(n) / opts.FPS.Float()
+	log.Printf("Budget: sampling %d runs of %d consecutive frames from %.1fs of video...", count, n, duration)
+	runs := make([][]v2btypes.Frame, 0, count)
+	for i := 0; i < count; i++ {
+		start := math.Max(0, duration*(float64(i)+0.5)/float64(count)-length/2)
+		frames, err := video2color.ExtractClip(ctx, opts.VideoPath, opts.FPS, opts.MaxWidth, start, length)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, frames[:min(len(frames), n)])
+	}
+	return runs, nil
+}
@@ -0,0 +1,241 @@
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"video2bas/basgen"
+	"video2bas/color2svg"
+	"video2bas/json2bas"
+	"video2bas/svg2json"
+	v2btypes "video2bas/type"
+	"video2bas/video2color"
+)
+
+// ErrUnreachable 表示降到最低一级参数仍放不下预算
+var ErrUnreachable = errors.New("budget cannot be reached")
+
+// Settings 为影响输出大小的一组参数
+type Settings struct {
+	Colors    int
+	FPS       v2btypes.Rate
+	Trace     color2svg.TraceOptions
+	Precision int
+}
+
+func (s Settings) String() string {
+	out := fmt.Sprintf("-colors %d -fps %s -precision %d -turdsize %d", s.Colors, s.FPS, s.Precision, s.Trace.TurdSize)
+	if s.Trace.Tracer == "polygon" {
+		return out + fmt.Sprintf(" -snap %g", s.Trace.Snap)
+	}
+	return out + fmt.Sprintf(" -opttolerance %g", s.Trace.OptTolerance)
+}
+
+// Options 控制预算估算
+type Options struct {
+	Budget    int64         // 全部分块的总字节数上限，0 为不限
+	Chunks    int           // 分块数上限，0 为不限
+	ChunkSize int           // 单个分块的最大字节数
+	Duration  float64       // 视频时长（秒）
+	MinFPS    v2btypes.Rate // 允许降低到的最低帧率
+	MinColors int           // 允许降低到的最少颜色数
+	Parallel  int
+	Split     video2color.SplitOptions
+	Strategy  string           // json2bas 输出策略
+	Generator json2bas.Options // 生成参数，viewBox、帧率、Path 与标识符由估算补全
+}
+
+// Result 为一组参数的估算结果
+type Result struct {
+	Settings
+	BytesPerFrame float64 // 样本帧的平均 BAS 字节数
+	Frames        int     // 该帧率下的总帧数
+	Estimate      int64   // 估算的总字节数
+	Chunks        int     // 估算的分块数
+	Fits          bool
+}
+
+// Fit 在样本帧上按 ladder 的顺序逐级降低参数，每级在不低于 MinFPS 的前提下选取能放入预算的最高帧率
+// （base 帧率的 1/k），返回第一个放得下的结果以及所有尝试过的结果。都放不下时返回最后一级在 MinFPS 下的结果
+// 与包装了 ErrUnreachable 的错误。
+// 样本为若干段按 base 帧率连续的帧，每段依次交给同一个生成器，使增量、补间与对象池的节省计入估算；
+// 帧率降为 1/k 时每段每 k 帧取一帧
+func Fit(samples [][]v2btypes.Frame, base Settings, opts Options) (Result, []Result, error) {
+	n := 0
+	for _, run := range samples {
+		n += len(run)
+	}
+	if n == 0 {
+		return Result{}, nil, errors.New("no sample frames")
+	}
+	if opts.Budget <= 0 && opts.Chunks <= 0 {
+		return Result{}, nil, errors.New("neither a byte budget nor a chunk count given")
+	}
+	if opts.Duration <= 0 || opts.ChunkSize <= 0 {
+		return Result{}, nil, errors.New("duration and chunk size must be positive")
+	}
+	if _, err := json2bas.NewGenerator(opts.Strategy, opts.Generator); err != nil {
+		return Result{}, nil, err
+	}
+	// 未指定最低帧率时不降低帧率
+	minFPS := opts.MinFPS
+	if !minFPS.Valid() {
+		minFPS = base.FPS
+	}
+	var tried []Result
+	for _, s := range ladder(base, opts.MinColors) {
+		runs, err := trace(samples, s, opts)
+		if err != nil {
+			return Result{}, tried, err
+		}
+		var r Result
+		for k := int64(1); ; k++ {
+			s.FPS = base.FPS.Div(k)
+			if k > 1 && s.FPS.Float() < minFPS.Float() {
+				break
+			}
+			r = estimate(s, measure(runs, int(k), s, opts), opts)
+			if r.Fits {
+				break
+			}
+		}
+		tried = append(tried, r)
+		if r.Fits {
+			return r, tried, nil
+		}
+	}
+	last := tried[len(tried)-1]
+	return last, tried, fmt.Errorf("%w: lowest settings %s need an estimated %d bytes in %d chunks",
+		ErrUnreachable, last.Settings, last.Estimate, last.Chunks)
+}
+
+// estimate 按平均帧大小估算总字节数与分块数。单帧不会被拆分，每个分块末尾按浪费一帧的空间计算
+func estimate(s Settings, perFrame float64, opts Options) Result {
+	frames := int(math.Ceil(opts.Duration * s.FPS.Float()))
+	total := int64(math.Ceil(perFrame * float64(frames)))
+	chunks := int(math.Ceil(float64(total) / math.Max(1, float64(opts.ChunkSize)-perFrame)))
+	fits := (opts.Budget <= 0 || total <= opts.Budget) && (opts.Chunks <= 0 || chunks <= opts.Chunks)
+	return Result{Settings: s, BytesPerFrame: perFrame, Frames: frames, Estimate: total, Chunks: chunks, Fits: fits}
+}
+
+// ladder 返回按画质损失从小到大排列的参数：降低坐标精度、粗化描摹、减少颜色
+func ladder(base Settings, minColors int) []Settings {
+	steps := []Settings{base}
+	cur := base
+	if cur.Precision > 0 {
+		cur.Precision = 0
+		steps = append(steps, cur)
+	}
+	for _, level := range []struct {
+		turd      int
+		tolerance float64
+		snap      float64
+	}{{4, 0.5, 2}, {8, 1, 4}} {
+		cur.Trace.TurdSize = max(cur.Trace.TurdSize, level.turd)
+		cur.Trace.OptTolerance = math.Max(cur.Trace.OptTolerance, level.tolerance)
+		cur.Trace.Snap = math.Max(cur.Trace.Snap, level.snap)
+		steps = append(steps, cur)
+	}
+	if minColors < 2 {
+		minColors = 2
+	}
+	for cur.Colors > minColors {
+		cur.Colors--
+		steps = append(steps, cur)
+	}
+	return steps
+}
+
+// trace 以 s 分层并描摹全部样本帧，返回各段的帧数据
+func trace(samples [][]v2btypes.Frame, s Settings, opts Options) ([][]v2btypes.FrameData, error) {
+	runs := make([][]v2btypes.FrameData, len(samples))
+	errs := make([][]error, len(samples))
+	parallel := opts.Parallel
+	if parallel <= 0 {
+		parallel = 1
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, parallel)
+	for i, run := range samples {
+		runs[i] = make([]v2btypes.FrameData, len(run))
+		errs[i] = make([]error, len(run))
+		for j, frame := range run {
+			wg.Add(1)
+			go func(i, j int, frame v2btypes.Frame) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				runs[i][j], errs[i][j] = traceFrame(frame, s, opts)
+			}(i, j, frame)
+		}
+	}
+	wg.Wait()
+	for _, run := range errs {
+		for _, err := range run {
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return runs, nil
+}
+
+func traceFrame(frame v2btypes.Frame, s Settings, opts Options) (v2btypes.FrameData, error) {
+	layers, err := video2color.SplitColorsAutoWithOptions(frame, s.Colors, opts.Split)
+	if err != nil {
+		return v2btypes.FrameData{}, err
+	}
+	svgs, err := color2svg.ConvertToSVGWithOptions([]v2btypes.FrameLayers{layers}, s.Trace, nil)
+	if err != nil {
+		return v2btypes.FrameData{}, err
+	}
+	var fd v2btypes.FrameData
+	if data := svg2json.ParseAllFrame(svgs); len(data) > 0 {
+		fd = data[0]
+	}
+	return fd, nil
+}
+
+// measure 以 s 的帧率与精度逐段生成 BAS 代码，每段每 k 帧取一帧，返回每帧的平均字节数（含换行与收尾代码）。
+// 样本中的对象远少于全片，先统计每帧新建的对象数，再让分配器跳过全片预计的对象数后重新生成，
+// 使标识符按全片最长的长度计入，宁可略微高估
+func measure(runs [][]v2btypes.FrameData, k int, s Settings, opts Options) float64 {
+	counter := basgen.NewIDAllocator(false)
+	_, frames := generate(runs, k, s, opts, func() *basgen.IDAllocator { return counter })
+	objects := float64(counter.Mark()) / float64(max(frames, 1)) * math.Ceil(opts.Duration*s.FPS.Float())
+	total, frames := generate(runs, k, s, opts, func() *basgen.IDAllocator {
+		ids := basgen.NewIDAllocator(false)
+		ids.Skip(int(math.Ceil(objects)))
+		return ids
+	})
+	return float64(total) / float64(max(frames, 1))
+}
+
+// generate 逐段生成 BAS 代码，每段使用 ids 返回的分配器，返回总字节数与生成的帧数
+func generate(runs [][]v2btypes.FrameData, k int, s Settings, opts Options, ids func() *basgen.IDAllocator) (int, int) {
+	total, frames := 0, 0
+	for _, run := range runs {
+		if len(run) == 0 {
+			continue
+		}
+		genOpts := opts.Generator
+		genOpts.ViewBox = run[0].ViewBox
+		genOpts.Rate = s.FPS
+		genOpts.StartTime = 0
+		genOpts.Path.Precision = s.Precision
+		genOpts.IDs = ids()
+		gen, _ := json2bas.NewGenerator(opts.Strategy, genOpts)
+		for i := 0; i < len(run); i += k {
+			fd := run[i]
+			fd.FrameIndex = i / k
+			text, _ := gen.Frame(fd)
+			total += len(text) + 1
+			frames++
+		}
+		if tail := gen.Tail(); tail != "" {
+			total += len(tail) + 1
+		}
+	}
+	return total, frames
+}
@@ -0,0 +1,111 @@
+package budget
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+	"video2bas/color2svg"
+	"video2bas/json2bas"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+	"video2bas/video2color"
+)
+
+// staticRun 返回 n 帧相同的画面：白底上一个红色方块
+func staticRun(n int) []v2btypes.Frame {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 24))
+	for y := 0; y < 24; y++ {
+		for x := 0; x < 32; x++ {
+			c := color.RGBA{255, 255, 255, 255}
+			if x >= 8 && x < 20 && y >= 6 && y < 18 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	run := make([]v2btypes.Frame, n)
+	for i := range run {
+		run[i] = v2btypes.Frame{Index: i, Image: img}
+	}
+	return run
+}
+
+// 增量模式下连续不变的帧只延长显示，估算的每帧大小应明显小于逐帧输出
+func TestFitMeasuresWithGenerator(t *testing.T) {
+	samples := [][]v2btypes.Frame{staticRun(8), staticRun(8)}
+	base := Settings{Colors: 2, FPS: v2btypes.Rate{Num: 10, Den: 1}, Trace: color2svg.DefaultTraceOptions(), Precision: 1}
+	perFrame := func(strategy string, delta bool) float64 {
+		r, _, err := Fit(samples, base, Options{
+			Budget:    1 << 40,
+			ChunkSize: 1 << 20,
+			Duration:  10,
+			Split:     video2color.DefaultSplitOptions(),
+			Strategy:  strategy,
+			Generator: json2bas.Options{Path: pathdata.DefaultOptions(), Delta: delta},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r.BytesPerFrame
+	}
+	frame := perFrame(json2bas.StrategyFrame, false)
+	delta := perFrame(json2bas.StrategyFrame, true)
+	pool := perFrame(json2bas.StrategyPool, false)
+	if frame <= 0 {
+		t.Fatalf("frame strategy measured %.1f bytes/frame", frame)
+	}
+	if delta > frame/3 {
+		t.Errorf("delta measured %.1f bytes/frame, frame strategy %.1f", delta, frame)
+	}
+	if pool >= frame {
+		t.Errorf("pool measured %.1f bytes/frame, frame strategy %.1f", pool, frame)
+	}
+}
+
+// 标识符按全片预计的对象数计算长度，时长越长估算的每帧大小越大
+func TestFitCountsIDLength(t *testing.T) {
+	samples := [][]v2btypes.Frame{staticRun(4)}
+	base := Settings{Colors: 2, FPS: v2btypes.Rate{Num: 10, Den: 1}, Trace: color2svg.DefaultTraceOptions(), Precision: 1}
+	perFrame := func(duration float64) float64 {
+		r, _, err := Fit(samples, base, Options{
+			Budget:    1 << 40,
+			ChunkSize: 1 << 20,
+			Duration:  duration,
+			Split:     video2color.DefaultSplitOptions(),
+			Strategy:  json2bas.StrategyFrame,
+			Generator: json2bas.Options{Path: pathdata.DefaultOptions()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r.BytesPerFrame
+	}
+	short, long := perFrame(1), perFrame(1e5)
+	if long <= short {
+		t.Errorf("measured %.1f bytes/frame for a long video, %.1f for a short one", long, short)
+	}
+}
+
+// 最低一级参数仍放不下时返回 ErrUnreachable，同时给出最后一级的结果
+func TestFitUnreachable(t *testing.T) {
+	base := Settings{Colors: 3, FPS: v2btypes.Rate{Num: 10, Den: 1}, Trace: color2svg.DefaultTraceOptions(), Precision: 1}
+	r, tried, err := Fit([][]v2btypes.Frame{staticRun(1)}, base, Options{
+		Budget:    10,
+		ChunkSize: 1 << 20,
+		Duration:  10,
+		MinFPS:    v2btypes.Rate{Num: 5, Den: 1},
+		Split:     video2color.DefaultSplitOptions(),
+		Strategy:  json2bas.StrategyFrame,
+		Generator: json2bas.Options{Path: pathdata.DefaultOptions()},
+	})
+	if !errors.Is(err, ErrUnreachable) {
+		t.Fatalf("got error %v, want ErrUnreachable", err)
+	}
+	if r.Fits || len(tried) == 0 || r != tried[len(tried)-1] {
+		t.Errorf("got %+v, want the last of %d tried results", r, len(tried))
+	}
+	if r.Colors != 2 || r.FPS.Float() != 5 {
+		t.Errorf("last result uses %d colors at %s fps, want 2 at 5", r.Colors, r.FPS)
+	}
+}
@@ -1,84 +1,253 @@
 package color2svg
 
 import (
-	"bytes"
-	"image"
+	"context"
+	"fmt"
+	"image/color"
+	"strings"
+	"sync"
+	"sync/atomic"
 	v2btypes "video2bas/type"
 
 	"github.com/gotranspile/gotrace"
 )
 
-// ConvertToSVG 使用 gotrace 将 FrameLayers 转成 SVG
+// TraceOptions 控制描摹参数，用于在细节与输出体积之间取舍
+type TraceOptions struct {
+	TurdSize     int             // 面积不超过该值的斑点将被忽略
+	TurnPolicy   int             // 路径分叉时的转向策略，取值见 gotrace.Turn*
+	AlphaMax     float64         // 拐角平滑度，0 为全部折线，越大越圆滑
+	OptiCurve    bool            // 是否合并相邻贝塞尔曲线
+	OptTolerance float64         // 曲线合并容差
+	Threshold    uint8           // 灰度低于该值的像素视为前景
+	SVG          bool            // 是否同时生成 SVG 文本（调试或导出用）
+	Tracer       string          // 描摹器：potrace（平滑曲线）或 polygon（像素多边形）
+	Snap         float64         // polygon 描摹器的拐角吸附网格（像素），大于 1 时去掉细小台阶并保持轴对齐，窄于网格的细节会被抹平
+	Coords       v2btypes.Coords // 输出坐标空间，Scale 为 0 时每像素 Unit 个单位
+}
+
+// Unit 为默认每像素对应的 viewBox 单位，与 gotrace SVG 后端的默认精度一致
+const Unit = 10
+
+// DefaultTraceOptions 返回与 gotrace 默认值一致的参数
+func DefaultTraceOptions() TraceOptions {
+	conf := gotrace.DefaultConfig()
+	return TraceOptions{
+		TurdSize:     conf.TurdSize,
+		TurnPolicy:   conf.TurnPolicy,
+		AlphaMax:     conf.AlphaMax,
+		OptiCurve:    conf.OptiCurve,
+		OptTolerance: conf.OptTolerance,
+		Threshold:    127,
+		Tracer:       "potrace",
+		Coords:       v2btypes.Coords{Scale: Unit},
+	}
+}
+
+// coords 返回输出坐标空间，未设置缩放时每像素 Unit 个单位
+func (opts TraceOptions) coords() v2btypes.Coords {
+	c := opts.Coords
+	if c.Scale <= 0 {
+		c.Scale = Unit
+	}
+	return c
+}
+
+var turnPolicies = map[string]int{
+	"black":    gotrace.TurnBlack,
+	"white":    gotrace.TurnWhite,
+	"left":     gotrace.TurnLeft,
+	"right":    gotrace.TurnRight,
+	"minority": gotrace.TurnMinority,
+	"majority": gotrace.TurnMajority,
+	"random":   gotrace.TurnRandom,
+}
+
+// ParseTurnPolicy 将策略名（black/white/left/right/minority/majority/random）转为 gotrace 常量
+func ParseTurnPolicy(name string) (int, error) {
+	p, ok := turnPolicies[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown turn policy %q", name)
+	}
+	return p, nil
+}
+
+// ConvertToSVG 使用默认描摹器将 FrameLayers 转成矢量路径
 func ConvertToSVG(frames []v2btypes.FrameLayers) ([]v2btypes.FrameSVG, error) {
+	return ConvertToSVGWithOptions(frames, DefaultTraceOptions(), nil)
+}
+
+// ConvertToSVGWithProgress 支持进度回调
+func ConvertToSVGWithProgress(frames []v2btypes.FrameLayers, progress func()) ([]v2btypes.FrameSVG, error) {
+	return ConvertToSVGWithOptions(frames, DefaultTraceOptions(), progress)
+}
+
+// ConvertToSVGWithOptions 使用指定描摹参数转换，支持进度回调
+func ConvertToSVGWithOptions(frames []v2btypes.FrameLayers, opts TraceOptions, progress func()) ([]v2btypes.FrameSVG, error) {
+	tracer, err := NewTracer(opts)
+	if err != nil {
+		return nil, err
+	}
 	result := make([]v2btypes.FrameSVG, len(frames))
 
 	for fi, frame := range frames {
 		fsvg := v2btypes.FrameSVG{
 			FrameIndex: frame.Index,
+			ViewBox:    frameViewBox(frame, opts),
 			Layers:     make([]v2btypes.LayerSVG, len(frame.Layers)),
+			Skipped:    frame.Skipped,
 		}
 
 		for li, layer := range frame.Layers {
-			svgStr, err := traceGrayToSVG(layer.Mask)
+			l, err := traceLayer(layer, tracer, pixelBox(frame), opts)
 			if err != nil {
 				return nil, err
 			}
-			fsvg.Layers[li] = v2btypes.LayerSVG{
-				ColorIndex: li,
-				Color:      layer.Color,
-				SVGData:    svgStr,
-			}
+			fsvg.Layers[li] = l
 		}
+		dropEmptyLayers(&fsvg)
 		result[fi] = fsvg
+		if progress != nil {
+			progress()
+		}
 	}
 
 	return result, nil
 }
 
-// ConvertToSVGWithProgress 支持进度回调
-func ConvertToSVGWithProgress(frames []v2btypes.FrameLayers, progress func()) ([]v2btypes.FrameSVG, error) {
-	result := make([]v2btypes.FrameSVG, len(frames))
+// ConvertToSVGParallel 并发描摹所有帧的所有图层，带并发上限和进度回调。
+// 结果顺序与输入一致；任一图层出错时取消其余任务并返回第一个错误。
+func ConvertToSVGParallel(ctx context.Context, frames []v2btypes.FrameLayers, opts TraceOptions, parallel int, progress func()) ([]v2btypes.FrameSVG, error) {
+	tracer, err := NewTracer(opts)
+	if err != nil {
+		return nil, err
+	}
+	if parallel <= 0 {
+		parallel = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
+	result := make([]v2btypes.FrameSVG, len(frames))
+	// 每帧剩余未完成的图层数，归零时回调进度
+	remaining := make([]int32, len(frames))
 	for fi, frame := range frames {
-		fsvg := v2btypes.FrameSVG{
+		result[fi] = v2btypes.FrameSVG{
 			FrameIndex: frame.Index,
+			ViewBox:    frameViewBox(frame, opts),
 			Layers:     make([]v2btypes.LayerSVG, len(frame.Layers)),
+			Skipped:    frame.Skipped,
+		}
+		remaining[fi] = int32(len(frame.Layers))
+		if len(frame.Layers) == 0 && progress != nil {
+			progress()
 		}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	sem := make(chan struct{}, parallel)
 
+dispatch:
+	for fi, frame := range frames {
 		for li, layer := range frame.Layers {
-			svgStr, err := traceGrayToSVG(layer.Mask)
-			if err != nil {
-				return nil, err
-			}
-			fsvg.Layers[li] = v2btypes.LayerSVG{
-				ColorIndex: li,
-				Color:      layer.Color,
-				SVGData:    svgStr,
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				break dispatch
 			}
-		}
-		result[fi] = fsvg
-		if progress != nil {
-			progress()
+			wg.Add(1)
+			go func(fi, li int, layer v2btypes.ColorLayer) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				if ctx.Err() != nil {
+					return
+				}
+				l, err := traceLayer(layer, tracer, pixelBox(frames[fi]), opts)
+				if err != nil {
+					fail(fmt.Errorf("frame %d layer %d: %w", frames[fi].Index, li, err))
+					return
+				}
+				result[fi].Layers[li] = l
+				if atomic.AddInt32(&remaining[fi], -1) == 0 && progress != nil {
+					progress()
+				}
+			}(fi, li, layer)
 		}
 	}
 
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for fi := range result {
+		dropEmptyLayers(&result[fi])
+	}
 	return result, nil
 }
 
-// traceGrayToSVG 核心：使用 gotrace 将 image.Gray 转 SVG 字符串
-func traceGrayToSVG(mask *image.Gray) (string, error) {
-	bm := gotrace.BitmapFromGray(mask, nil)
+// pixelBox 返回以像素为单位的帧范围
+func pixelBox(frame v2btypes.FrameLayers) v2btypes.ViewBox {
+	w, h := frame.Width, frame.Height
+	if w == 0 && len(frame.Layers) > 0 {
+		sz := frame.Layers[0].Mask.Bounds().Size()
+		w, h = sz.X, sz.Y
+	}
+	return v2btypes.ViewBox{W: float64(w), H: float64(h)}
+}
 
-	paths, err := gotrace.Trace(bm, nil)
-	if err != nil {
-		return "", err
+// frameViewBox 由帧尺寸按 opts.Coords 计算 viewBox
+func frameViewBox(frame v2btypes.FrameLayers, opts TraceOptions) v2btypes.ViewBox {
+	vb, _ := opts.coords().Map(pixelBox(frame))
+	return vb
+}
+
+// dropEmptyLayers 去掉描摹后没有任何路径的图层（例如全部被 TurdSize 过滤）
+func dropEmptyLayers(fsvg *v2btypes.FrameSVG) {
+	kept := fsvg.Layers[:0]
+	for _, l := range fsvg.Layers {
+		if l.Path.Empty() {
+			fsvg.Skipped++
+			continue
+		}
+		kept = append(kept, l)
 	}
+	fsvg.Layers = kept
+}
 
-	var buf bytes.Buffer
-	sz := mask.Bounds().Size()
-	if err := gotrace.Render("svg", nil, &buf, paths, sz.X, sz.Y); err != nil {
-		return "", err
+// traceLayer 描摹单个图层，并将像素坐标映射到输出坐标空间
+func traceLayer(layer v2btypes.ColorLayer, tracer Tracer, src v2btypes.ViewBox, opts TraceOptions) (v2btypes.LayerSVG, error) {
+	path, err := tracer.Trace(layer.Mask)
+	if err != nil {
+		return v2btypes.LayerSVG{}, err
 	}
+	vb, toViewBox := opts.coords().Map(src)
+	path = path.Transform(toViewBox)
+	l := v2btypes.LayerSVG{
+		ColorIndex: layer.PaletteIndex,
+		Color:      layer.Color,
+		Path:       path,
+	}
+	if opts.SVG {
+		l.SVGData = RenderSVG(path, vb, layer.Color)
+	}
+	return l, nil
+}
 
-	return buf.String(), nil
+// RenderSVG 将路径渲染为独立的 SVG 文档
+func RenderSVG(path v2btypes.Path, vb v2btypes.ViewBox, fill color.RGBA) string {
+	return fmt.Sprintf("<svg xmlns=\"http://www.w3.org/2000/svg\" viewBox=\"%s\">\n<path fill=\"#%02x%02x%02x\" d=\"%s\"/>\n</svg>\n",
+		vb.String(), fill.R, fill.G, fill.B, path.SVG(0))
 }
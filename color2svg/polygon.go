@@ -0,0 +1,202 @@
+package color2svg
+
+import (
+	"image"
+	"math"
+	v2btypes "video2bas/type"
+)
+
+// polygonTracer 沿像素边界追踪轮廓，只输出轴对齐的折线（M/H/V/Z），
+// 适合像素画与低分辨率素材；snap > 1 时再把拐角吸附到网格以去掉细小的台阶，仍保持轴对齐
+type polygonTracer struct {
+	threshold uint8
+	turdSize  int
+	snap      float64
+}
+
+// 边的方向，前景始终位于行进方向右侧（y 轴向下时外轮廓为顺时针）
+const (
+	dirRight = iota
+	dirDown
+	dirLeft
+	dirUp
+)
+
+var dirDelta = [4]image.Point{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+
+// pixelEdge 表示一条像素边，pixel 为其所属的前景像素，用于在鞍点处保持 4 连通
+type pixelEdge struct {
+	dir   int
+	pixel int
+	used  bool
+}
+
+// Trace 追踪掩码中所有前景区域的外轮廓与孔洞
+func (t polygonTracer) Trace(mask *image.Gray) (v2btypes.Path, error) {
+	b := mask.Bounds()
+	w, h := b.Dx(), b.Dy()
+	fg := func(x, y int) bool {
+		if x < 0 || y < 0 || x >= w || y >= h {
+			return false
+		}
+		return mask.Pix[y*mask.Stride+x] < t.threshold
+	}
+
+	// 每个格点最多有两条出边（鞍点）
+	vw := w + 1
+	out := make([][]pixelEdge, vw*(h+1))
+	addEdge := func(x, y, dir, pixel int) {
+		v := y*vw + x
+		out[v] = append(out[v], pixelEdge{dir: dir, pixel: pixel})
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !fg(x, y) {
+				continue
+			}
+			px := y*w + x
+			if !fg(x, y-1) {
+				addEdge(x, y, dirRight, px)
+			}
+			if !fg(x+1, y) {
+				addEdge(x+1, y, dirDown, px)
+			}
+			if !fg(x, y+1) {
+				addEdge(x+1, y+1, dirLeft, px)
+			}
+			if !fg(x-1, y) {
+				addEdge(x, y+1, dirUp, px)
+			}
+		}
+	}
+
+	var path v2btypes.Path
+	for v := range out {
+		for e := range out[v] {
+			if out[v][e].used {
+				continue
+			}
+			ring := t.follow(out, vw, v, e)
+			if len(ring) < 3 || math.Abs(ringArea(ring)) <= float64(t.turdSize) {
+				continue
+			}
+			// 整个轮廓窄于网格时吸附会使其消失，保留原样
+			if t.snap > 1 {
+				if s := snapRing(ring, t.snap); len(s) >= 3 {
+					ring = s
+				}
+			}
+			sp := v2btypes.SubPath{Start: ring[0], Closed: true, Segments: make([]v2btypes.Segment, 0, len(ring)-1)}
+			for _, p := range ring[1:] {
+				sp.Segments = append(sp.Segments, v2btypes.Segment{Kind: v2btypes.SegLine, Pts: [3]v2btypes.Point{p}})
+			}
+			path.SubPaths = append(path.SubPaths, sp)
+		}
+	}
+	return path, nil
+}
+
+// follow 从格点 v 的第 e 条出边开始绕行一圈，返回去掉共线点后的拐角序列
+func (t polygonTracer) follow(out [][]pixelEdge, vw, v, e int) []v2btypes.Point {
+	var corners []v2btypes.Point
+	startV, startE := v, e
+	prevDir := -1
+	for {
+		edge := &out[v][e]
+		edge.used = true
+		if edge.dir != prevDir {
+			corners = append(corners, v2btypes.Point{X: float64(v % vw), Y: float64(v / vw)})
+		}
+		prevDir = edge.dir
+		d := dirDelta[edge.dir]
+		v = (v/vw+d.Y)*vw + v%vw + d.X
+
+		// 鞍点处优先选择属于同一像素的出边，使对角相邻的像素分属不同轮廓
+		next := -1
+		for i := range out[v] {
+			if out[v][i].used && !(v == startV && i == startE) {
+				continue
+			}
+			if next < 0 || out[v][i].pixel == edge.pixel {
+				next = i
+			}
+		}
+		if next < 0 || (v == startV && next == startE) {
+			break
+		}
+		e = next
+	}
+	// 起点恰在一条直线中间时合并首尾
+	if len(corners) > 2 && out[startV][startE].dir == prevDir {
+		corners = corners[1:]
+	}
+	return corners
+}
+
+// ringArea 计算闭合多边形的有向面积
+func ringArea(ring []v2btypes.Point) float64 {
+	a := 0.0
+	for i := range ring {
+		p, q := ring[i], ring[(i+1)%len(ring)]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	return a / 2
+}
+
+// ringLevel 为轴对齐多边形的一条边：水平边记其 y，竖直边记其 x
+type ringLevel struct {
+	v     float64
+	horiz bool
+}
+
+// snapRing 简化轴对齐多边形：各边吸附到间距为 grid 的网格上，再去掉长度为零的边并合并共线边。
+// 每条边的偏移不超过 grid/2，结果仍只含水平与竖直边；窄于网格的凸起或缺口会被抹平，
+// 整个多边形退化为零面积时返回 nil
+func snapRing(ring []v2btypes.Point, grid float64) []v2btypes.Point {
+	n := len(ring)
+	if n < 4 || n%2 != 0 {
+		return ring
+	}
+	levels := make([]ringLevel, n)
+	for i, p := range ring {
+		q := ring[(i+1)%n]
+		l := ringLevel{v: p.X}
+		if p.Y == q.Y {
+			l = ringLevel{v: p.Y, horiz: true}
+		}
+		l.v = math.Floor(l.v/grid+0.5) * grid
+		levels[i] = l
+	}
+
+	// 长度为零的边两侧的边同向且共线，删去该边与其后一条边即完成合并
+	for changed := true; changed && len(levels) > 4; {
+		changed = false
+		for i := 0; i < len(levels) && len(levels) > 4; i++ {
+			m := len(levels)
+			prev, next := (i+m-1)%m, (i+1)%m
+			if levels[prev].v != levels[next].v {
+				continue
+			}
+			if next == 0 {
+				levels = levels[1 : m-1]
+			} else {
+				levels = append(levels[:i], levels[i+2:]...)
+			}
+			changed = true
+		}
+	}
+
+	out := make([]v2btypes.Point, len(levels))
+	for i, l := range levels {
+		prev := levels[(i+len(levels)-1)%len(levels)].v
+		if l.horiz {
+			out[i] = v2btypes.Point{X: prev, Y: l.v}
+		} else {
+			out[i] = v2btypes.Point{X: l.v, Y: prev}
+		}
+	}
+	if ringArea(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,165 @@
+package color2svg
+
+import (
+	"image"
+	"math"
+	"testing"
+	v2btypes "video2bas/type"
+)
+
+// maskOf 由字符画生成掩码，# 为前景
+func maskOf(rows ...string) *image.Gray {
+	m := image.NewGray(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x := range row {
+			m.Pix[y*m.Stride+x] = 255
+			if row[x] == '#' {
+				m.Pix[y*m.Stride+x] = 0
+			}
+		}
+	}
+	return m
+}
+
+// rings 返回各子路径的拐角序列，并检查每条边都是水平或竖直的
+func rings(t *testing.T, p v2btypes.Path) [][]v2btypes.Point {
+	t.Helper()
+	var out [][]v2btypes.Point
+	for _, sp := range p.SubPaths {
+		if !sp.Closed {
+			t.Fatalf("open subpath %+v", sp)
+		}
+		ring := []v2btypes.Point{sp.Start}
+		for _, seg := range sp.Segments {
+			if seg.Kind != v2btypes.SegLine {
+				t.Fatalf("non-line segment %+v", seg)
+			}
+			ring = append(ring, seg.Pts[0])
+		}
+		for i, a := range ring {
+			b := ring[(i+1)%len(ring)]
+			if a.X != b.X && a.Y != b.Y {
+				t.Fatalf("diagonal edge %v -> %v", a, b)
+			}
+		}
+		out = append(out, ring)
+	}
+	return out
+}
+
+// 外轮廓在 y 轴向下时为顺时针（有向面积为正），孔洞方向相反
+func TestPolygonOrientationAndHoles(t *testing.T) {
+	tr := polygonTracer{threshold: 128}
+	path, err := tr.Trace(maskOf(
+		"......",
+		".####.",
+		".#..#.",
+		".####.",
+		"......",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := rings(t, path)
+	if len(rs) != 2 {
+		t.Fatalf("got %d rings, want outer and hole", len(rs))
+	}
+	var areas []float64
+	for _, r := range rs {
+		areas = append(areas, ringArea(r))
+	}
+	if areas[0] < areas[1] {
+		areas[0], areas[1] = areas[1], areas[0]
+	}
+	if areas[0] != 12 || areas[1] != -2 {
+		t.Errorf("ring areas %v, want 12 and -2", areas)
+	}
+	// 去掉共线点后只剩拐角
+	for _, r := range rs {
+		if len(r) != 4 {
+			t.Errorf("ring %v has %d corners, want 4", r, len(r))
+		}
+	}
+}
+
+// 对角相邻的两个像素分属不同轮廓
+func TestPolygonDiagonalPixels(t *testing.T) {
+	path, err := polygonTracer{threshold: 128}.Trace(maskOf(
+		"#.",
+		".#",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := rings(t, path)
+	if len(rs) != 2 {
+		t.Fatalf("got %d rings, want 2", len(rs))
+	}
+	for _, r := range rs {
+		if a := ringArea(r); a != 1 {
+			t.Errorf("ring %v has area %v, want 1", r, a)
+		}
+	}
+}
+
+// 吸附后拐角都在网格上、边仍轴对齐，面积变化有界，窄于网格的台阶被抹平
+func TestPolygonSnap(t *testing.T) {
+	rows := []string{
+		"################...",
+		"#################..",
+		"################...",
+		"################...",
+		".###############...",
+		"################...",
+		"################...",
+		"################...",
+	}
+	const grid = 4
+	traced, err := polygonTracer{threshold: 128}.Trace(maskOf(rows...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapped, err := polygonTracer{threshold: 128, snap: grid}.Trace(maskOf(rows...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, after := rings(t, traced), rings(t, snapped)
+	if len(after) != 1 {
+		t.Fatalf("got %d rings, want 1", len(after))
+	}
+	if len(after[0]) != 4 {
+		t.Errorf("snapped ring %v, want the notch and bump removed", after[0])
+	}
+	for _, p := range after[0] {
+		if math.Mod(p.X, grid) != 0 || math.Mod(p.Y, grid) != 0 {
+			t.Errorf("corner %v is off the %d px grid", p, grid)
+		}
+	}
+	// 每条边最多移动半个网格
+	a, b := ringArea(before[0]), ringArea(after[0])
+	perimeter := 2.0 * (16 + 8)
+	if math.Abs(a-b) > perimeter*grid/2 {
+		t.Errorf("area changed from %v to %v", a, b)
+	}
+}
+
+// 整个轮廓窄于网格时保留原样，不会消失
+func TestPolygonSnapKeepsThinShapes(t *testing.T) {
+	rows := []string{
+		"..........",
+		".##.......",
+		".##.......",
+		".##.......",
+		".##.......",
+		".##.......",
+		"..........",
+	}
+	path, err := polygonTracer{threshold: 128, snap: 8}.Trace(maskOf(rows...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := rings(t, path)
+	if len(rs) != 1 || ringArea(rs[0]) != 10 {
+		t.Errorf("thin bar traced as %v, want the original 2x5 outline", rs)
+	}
+}
@@ -0,0 +1,100 @@
+package color2svg
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	v2btypes "video2bas/type"
+
+	"github.com/gotranspile/gotrace"
+)
+
+// Tracer 将单色掩码描摹为路径，输出坐标以像素为单位、y 轴向下。
+// 实现需可被多个协程同时调用
+type Tracer interface {
+	Trace(mask *image.Gray) (v2btypes.Path, error)
+}
+
+// NewTracer 按 opts.Tracer 创建描摹器
+func NewTracer(opts TraceOptions) (Tracer, error) {
+	switch opts.Tracer {
+	case "", "potrace":
+		return potraceTracer{opts: opts}, nil
+	case "polygon":
+		return polygonTracer{threshold: opts.Threshold, turdSize: opts.TurdSize, snap: opts.Snap}, nil
+	default:
+		return nil, fmt.Errorf("unknown tracer %q", opts.Tracer)
+	}
+}
+
+// potraceTracer 使用 gotrace 拟合平滑的贝塞尔曲线
+type potraceTracer struct {
+	opts TraceOptions
+}
+
+func (t potraceTracer) config() *gotrace.Config {
+	conf := gotrace.DefaultConfig()
+	conf.TurdSize = t.opts.TurdSize
+	conf.TurnPolicy = t.opts.TurnPolicy
+	conf.AlphaMax = t.opts.AlphaMax
+	conf.OptiCurve = t.opts.OptiCurve
+	conf.OptTolerance = t.opts.OptTolerance
+	return conf
+}
+
+// Trace 使用 gotrace 描摹 image.Gray
+func (t potraceTracer) Trace(mask *image.Gray) (v2btypes.Path, error) {
+	bm := gotrace.BitmapFromGray(mask, func(c color.Gray) bool {
+		return c.Y < t.opts.Threshold
+	})
+
+	paths, err := gotrace.Trace(bm, t.config())
+	if err != nil {
+		return v2btypes.Path{}, err
+	}
+
+	// gotrace 的位图 y 轴向上
+	h := float64(mask.Bounds().Dy())
+	conv := func(p gotrace.DPoint) v2btypes.Point {
+		return v2btypes.Point{X: p.X, Y: h - p.Y}
+	}
+	var out v2btypes.Path
+	appendPathTree(&out, paths, conv)
+	return out, nil
+}
+
+// appendPathTree 按 gotrace SVG 后端相同的顺序遍历路径树：外轮廓、其孔洞、再递归孔洞内的图形
+func appendPathTree(out *v2btypes.Path, tree *gotrace.Path, conv func(gotrace.DPoint) v2btypes.Point) {
+	for p := tree; p != nil; p = p.Sibling {
+		out.SubPaths = append(out.SubPaths, curveToSubPath(p.Curve, conv))
+		for q := p.Childlist; q != nil; q = q.Sibling {
+			out.SubPaths = append(out.SubPaths, curveToSubPath(q.Curve, conv))
+		}
+		for q := p.Childlist; q != nil; q = q.Sibling {
+			appendPathTree(out, q.Childlist, conv)
+		}
+	}
+}
+
+func curveToSubPath(c gotrace.Curve, conv func(gotrace.DPoint) v2btypes.Point) v2btypes.SubPath {
+	sp := v2btypes.SubPath{
+		Start:    conv(c.C[c.N-1][2]),
+		Segments: make([]v2btypes.Segment, 0, c.N*2),
+		Closed:   true,
+	}
+	for i := 0; i < c.N; i++ {
+		switch c.Tag[i] {
+		case gotrace.POTRACE_CORNER:
+			sp.Segments = append(sp.Segments,
+				v2btypes.Segment{Kind: v2btypes.SegLine, Pts: [3]v2btypes.Point{conv(c.C[i][1])}},
+				v2btypes.Segment{Kind: v2btypes.SegLine, Pts: [3]v2btypes.Point{conv(c.C[i][2])}},
+			)
+		case gotrace.POTRACE_CURVETO:
+			sp.Segments = append(sp.Segments, v2btypes.Segment{
+				Kind: v2btypes.SegCubic,
+				Pts:  [3]v2btypes.Point{conv(c.C[i][0]), conv(c.C[i][1]), conv(c.C[i][2])},
+			})
+		}
+	}
+	return sp
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	v2btypes "video2bas/type"
+)
+
+// coordFlags 注册输出坐标空间相关的命令行参数
+type coordFlags struct {
+	scale   *float64
+	viewBox *string
+	fit     *string
+	player  *string
+}
+
+func addCoordFlags(fs *flag.FlagSet, scale float64, unit string) *coordFlags {
+	return &coordFlags{
+		scale:   fs.Float64("scale", scale, "每"+unit+"对应的 viewBox 单位，给出 -viewbox 时不使用"),
+		viewBox: fs.String("viewbox", "", "目标 viewBox 尺寸 WxH，给出时坐标按 -fit 映射到该范围，与源分辨率无关"),
+		fit:     fs.String("fit", string(v2btypes.FitLetterbox), "画面比例不一致时的适配方式：letterbox（等比留边）、fill（等比裁切）或 stretch（拉伸）"),
+		player:  fs.String("player", "16x9", "播放器画面比例 WxH，BAS 对象按 -fit 铺满其宽度或高度并居中"),
+	}
+}
+
+// Coords 返回命令行指定的坐标空间
+func (f *coordFlags) Coords() (v2btypes.Coords, error) {
+	fit, err := v2btypes.ParseFit(*f.fit)
+	if err != nil {
+		return v2btypes.Coords{}, err
+	}
+	c := v2btypes.Coords{Scale: *f.scale, Fit: fit}
+	if !(c.Scale > 0) {
+		return c, fmt.Errorf("scale must be positive: %v", c.Scale)
+	}
+	if *f.viewBox != "" {
+		if c.W, c.H, err = v2btypes.ParseSize(*f.viewBox); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// Player 返回命令行指定的播放器宽高比
+func (f *coordFlags) Player() (float64, error) {
+	w, h, err := v2btypes.ParseSize(*f.player)
+	if err != nil {
+		return 0, err
+	}
+	return w / h, nil
+}
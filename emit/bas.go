@@ -0,0 +1,185 @@
+package emit
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"video2bas/json2bas"
+	v2btypes "video2bas/type"
+)
+
+// BASOptions 控制 BAS 输出
+type BASOptions struct {
+	OutputPath string // 分块文件路径前缀，写出 <OutputPath>_<n>.bas.txt；为空时只生成分块不写文件
+	MaxSize    int    // 单个分块最大字节数
+	Strategy   string // json2bas 输出策略
+	Parallel   int    // frame 策略一次生成全部帧时的并行数
+	Rebase     bool   // 每个分块以其第一帧的开始时间为时间轴起点，供按分块设置发送时间的 XML 使用
+	Generator  json2bas.Options
+}
+
+// BAS 生成 BAS 代码并按最大尺寸切分为分块，单帧不会被拆分到两个分块。
+// 分块内的时间相对动画开始，设置 Rebase 时相对分块第一帧的开始时间；每个分块结束时依次交给各 ChunkHandler
+type BAS struct {
+	opts     BASOptions
+	handlers []ChunkHandler
+	gen      json2bas.Generator
+	genOpts  json2bas.Options
+
+	open  bool
+	index int
+	start int64 // 当前分块的时间轴起点（毫秒）
+	code  strings.Builder
+	stats json2bas.Stats // 当前分块
+	total json2bas.Stats
+}
+
+// NewBAS 创建 BAS 输出，策略无效时返回错误
+func NewBAS(opts BASOptions, handlers ...ChunkHandler) (*BAS, error) {
+	if _, err := json2bas.NewGenerator(opts.Strategy, opts.Generator); err != nil {
+		return nil, err
+	}
+	return &BAS{opts: opts, handlers: handlers}, nil
+}
+
+// Begin 以第一帧的 viewBox 创建生成器
+func (b *BAS) Begin(first v2btypes.FrameData) error {
+	b.genOpts = b.opts.Generator
+	if first.ViewBox.W <= 0 || first.ViewBox.H <= 0 {
+		return fmt.Errorf("frame %d: missing viewBox", first.FrameIndex)
+	}
+	b.genOpts.ViewBox = first.ViewBox
+	b.rebase(first)
+	var err error
+	b.gen, err = json2bas.NewGenerator(b.opts.Strategy, b.genOpts)
+	return err
+}
+
+// Frame 生成一帧并写入。当前分块放不下该帧及收尾代码时，
+// 先写入收尾代码结束分块，再在新分块中从头生成该帧
+func (b *BAS) Frame(fd v2btypes.FrameData) error {
+	tail := b.gen.Tail()
+	text, stats := b.gen.Frame(fd)
+	if b.open && b.code.Len()+len(text)+len(b.gen.Tail())+2 > b.opts.MaxSize {
+		b.writeTail(tail)
+		if err := b.closeChunk(); err != nil {
+			return err
+		}
+		b.rebase(fd)
+		b.gen.Reset(b.genOpts.StartTime)
+		text, stats = b.gen.Frame(fd)
+	}
+	b.write(text, stats)
+	return nil
+}
+
+// Frames 在 frame 策略下并行生成全部帧，其余策略与增量、补间模式依赖上一帧，逐帧生成
+func (b *BAS) Frames(data []v2btypes.FrameData) error {
+	if b.opts.Strategy != json2bas.StrategyFrame || b.genOpts.Delta || b.genOpts.Tween {
+		for _, fd := range data {
+			if err := b.Frame(fd); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// 分批生成，分块结束后剩余的帧以新分块的起点重新生成
+	batch := max(b.opts.Parallel, 1) * 8
+	ids := b.genOpts.IDs
+	for len(data) > 0 {
+		built := json2bas.BuildAll(data[:min(batch, len(data))], b.genOpts, b.opts.Parallel)
+		n := 0
+		for ; n < len(built); n++ {
+			mark := 0
+			if ids != nil {
+				mark = ids.Mark()
+			}
+			text, stats := built[n].Format(ids)
+			if b.open && b.code.Len()+len(text)+1 > b.opts.MaxSize {
+				if ids != nil {
+					ids.Rollback(mark)
+				}
+				break
+			}
+			b.write(text, stats)
+		}
+		data = data[n:]
+		if n < len(built) {
+			if err := b.closeChunk(); err != nil {
+				return err
+			}
+			b.rebase(data[0])
+		}
+	}
+	return nil
+}
+
+// End 写入收尾代码并结束最后一个分块
+func (b *BAS) End() error {
+	if b.gen != nil {
+		b.writeTail(b.gen.Tail())
+	}
+	return b.closeChunk()
+}
+
+// Chunks 返回已结束的分块数
+func (b *BAS) Chunks() int {
+	return b.index
+}
+
+// Total 返回全部分块的统计
+func (b *BAS) Total() json2bas.Stats {
+	return b.total
+}
+
+// rebase 在设置了 Rebase 时以 fd 的开始时间作为下一个分块的时间轴起点
+func (b *BAS) rebase(fd v2btypes.FrameData) {
+	if !b.opts.Rebase {
+		return
+	}
+	b.start = b.genOpts.FrameStart(fd)
+	b.genOpts.StartTime = float64(b.start)
+}
+
+// write 将一帧的文本写入当前分块
+func (b *BAS) write(text string, stats json2bas.Stats) {
+	b.open = true
+	b.writeLine(text)
+	b.stats.Add(stats)
+	b.total.Add(stats)
+}
+
+func (b *BAS) writeLine(text string) {
+	b.code.WriteString(text)
+	b.code.WriteByte('\n')
+}
+
+func (b *BAS) writeTail(tail string) {
+	if b.open && tail != "" {
+		b.writeLine(tail)
+	}
+}
+
+func (b *BAS) closeChunk() error {
+	if !b.open {
+		return nil
+	}
+	c := Chunk{Index: b.index, Start: b.start, Code: b.code.String(), Stats: b.stats}
+	b.open = false
+	b.index++
+	b.code.Reset()
+	b.stats = json2bas.Stats{}
+	if b.opts.OutputPath != "" {
+		c.Path = b.opts.OutputPath + "_" + strconv.Itoa(c.Index) + ".bas.txt"
+		if err := os.WriteFile(c.Path, []byte(c.Code), 0o644); err != nil {
+			return err
+		}
+	}
+	for _, h := range b.handlers {
+		if err := h.Chunk(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package emit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"video2bas/basgen"
+	"video2bas/json2bas"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+type chunkList []Chunk
+
+func (l *chunkList) Chunk(c Chunk) error {
+	*l = append(*l, c)
+	return nil
+}
+
+// 分块内没有负的时长，加上分块起点后各帧的显示时间与时间轴一致；
+// Rebase 时每个分块以第一帧的开始时间为起点，否则起点为 0，代码中都不带起点注释
+func TestChunkTiming(t *testing.T) {
+	rate, err := v2btypes.ParseRate("25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := make([]v2btypes.FrameData, 200)
+	for i := range frames {
+		frames[i] = v2btypes.FrameData{
+			FrameIndex: i,
+			ViewBox:    v2btypes.ViewBox{W: 100, H: 100},
+			Layers:     []v2btypes.Layer{{Color: "FFFFFF", PathData: fmt.Sprintf("M0 0H%dV10H0z", i%50+1), Alpha: 1}},
+		}
+	}
+	for _, tc := range []struct {
+		name     string
+		strategy string
+		batch    bool
+		rebase   bool
+	}{
+		{"frame", json2bas.StrategyFrame, false, false},
+		{"frame-batch", json2bas.StrategyFrame, true, false},
+		{"pool", json2bas.StrategyPool, false, false},
+		{"frame-rebase", json2bas.StrategyFrame, false, true},
+		{"frame-batch-rebase", json2bas.StrategyFrame, true, true},
+		{"pool-rebase", json2bas.StrategyPool, false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var chunks chunkList
+			b, err := NewBAS(BASOptions{
+				MaxSize:   2000,
+				Strategy:  tc.strategy,
+				Parallel:  4,
+				Rebase:    tc.rebase,
+				Generator: json2bas.Options{Rate: rate, Path: pathdata.DefaultOptions()},
+			}, &chunks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := b.Begin(frames[0]); err != nil {
+				t.Fatal(err)
+			}
+			if tc.batch {
+				err = b.Frames(frames)
+			} else {
+				for _, fd := range frames {
+					if err = b.Frame(fd); err != nil {
+						break
+					}
+				}
+			}
+			if err == nil {
+				err = b.End()
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(chunks) < 2 {
+				t.Fatalf("got %d chunks, want several", len(chunks))
+			}
+
+			var shows []int64
+			for _, c := range chunks {
+				if strings.HasPrefix(c.Code, "//") {
+					t.Errorf("chunk %d starts with a comment: %q", c.Index, c.Code[:strings.IndexByte(c.Code, '\n')])
+				}
+				if !tc.rebase && c.Start != 0 {
+					t.Errorf("chunk %d: start %dms without rebase", c.Index, c.Start)
+				}
+				nodes, err := basgen.Parse(c.Code)
+				if err != nil {
+					t.Fatalf("chunk %d: %v", c.Index, err)
+				}
+				var first int64 = -1
+				for _, n := range nodes {
+					chain, ok := n.Stmt.(basgen.Chain)
+					if !ok {
+						continue
+					}
+					var at int64
+					for _, step := range chain {
+						if step.Duration < 0 {
+							t.Fatalf("chunk %d line %d: negative duration %dms", c.Index, n.Line, step.Duration)
+						}
+						for _, p := range step.Props {
+							if v, ok := p.Value.(basgen.Num); p.Key == "alpha" && ok && v > 0 {
+								shows = append(shows, c.Start+at)
+								if first < 0 {
+									first = at
+								}
+							}
+						}
+						at += step.Duration
+					}
+				}
+				if tc.rebase && first != 0 {
+					t.Errorf("chunk %d: first frame shows at %dms, want 0ms", c.Index, first)
+				}
+			}
+			if len(shows) != len(frames) {
+				t.Fatalf("got %d shown frames, want %d", len(shows), len(frames))
+			}
+			for i, at := range shows {
+				if want := rate.FrameStart(i); at != want {
+					t.Fatalf("frame %d shows at %dms, want %dms", i, at, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package emit
+
+import (
+	"fmt"
+	"strings"
+	"video2bas/json2bas"
+	v2btypes "video2bas/type"
+)
+
+// 支持的输出格式
+const (
+	FormatBAS    = "bas"    // 按大小分块的 .bas.txt
+	FormatXML    = "xml"    // 哔哩哔哩弹幕 XML，每个 BAS 分块一条高级弹幕
+	FormatSVG    = "svg"    // SMIL 动画 SVG
+	FormatHTML   = "html"   // 叠加在源视频上的 HTML 预览页
+	FormatASS    = "ass"    // ASS 字幕
+	FormatLottie = "lottie" // Lottie JSON
+	FormatJSONL  = "jsonl"  // 中间 FrameData
+)
+
+// Formats 为全部输出格式
+var Formats = []string{FormatBAS, FormatXML, FormatSVG, FormatHTML, FormatASS, FormatLottie, FormatJSONL}
+
+// ParseFormats 解析逗号分隔的格式列表，去除重复项
+func ParseFormats(s string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || seen[f] {
+			continue
+		}
+		known := false
+		for _, k := range Formats {
+			known = known || k == f
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown output format %q, expected one of %s", f, strings.Join(Formats, ","))
+		}
+		seen[f] = true
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// Emitter 为一种输出格式。第一帧到来时调用 Begin，随后按帧顺序调用 Frame，全部结束后调用 End。
+// 没有任何帧时只调用 End
+type Emitter interface {
+	Begin(first v2btypes.FrameData) error
+	Frame(fd v2btypes.FrameData) error
+	End() error
+}
+
+// Batch 为可一次处理全部帧的 Emitter，实现时代替逐帧调用 Frame，以便并行生成
+type Batch interface {
+	Frames(data []v2btypes.FrameData) error
+}
+
+// Chunk 为 BAS 输出的一个分块
+type Chunk struct {
+	Index int
+	Start int64  // 分块的时间轴起点（毫秒），分块内的时间均相对该时间；设置 BASOptions.Rebase 时为第一帧的开始时间，否则为 0
+	Path  string // 写出的文件，未写文件时为空
+	Code  string
+	Stats json2bas.Stats
+}
+
+// ChunkHandler 在每个 BAS 分块结束时被调用
+type ChunkHandler interface {
+	Chunk(c Chunk) error
+}
+
+// Multi 将帧依次分发给多个 Emitter
+type Multi struct {
+	emitters []Emitter
+	begun    bool
+}
+
+// NewMulti 创建分发到 emitters 的 Multi
+func NewMulti(emitters ...Emitter) *Multi {
+	return &Multi{emitters: emitters}
+}
+
+// Add 追加一个 Emitter，须在第一帧之前调用
+func (m *Multi) Add(e Emitter) {
+	m.emitters = append(m.emitters, e)
+}
+
+func (m *Multi) begin(first v2btypes.FrameData) error {
+	if m.begun {
+		return nil
+	}
+	m.begun = true
+	for _, e := range m.emitters {
+		if err := e.Begin(first); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Frame 分发一帧
+func (m *Multi) Frame(fd v2btypes.FrameData) error {
+	if err := m.begin(fd); err != nil {
+		return err
+	}
+	for _, e := range m.emitters {
+		if err := e.Frame(fd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Frames 分发全部帧，实现了 Batch 的 Emitter 一次处理
+func (m *Multi) Frames(data []v2btypes.FrameData) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if err := m.begin(data[0]); err != nil {
+		return err
+	}
+	for _, e := range m.emitters {
+		if b, ok := e.(Batch); ok {
+			if err := b.Frames(data); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, fd := range data {
+			if err := e.Frame(fd); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// End 结束全部 Emitter，返回遇到的第一个错误
+func (m *Multi) End() error {
+	var first error
+	for _, e := range m.emitters {
+		if err := e.End(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// frameFuncs 将逐帧写出的编码器适配为 Emitter
+type frameFuncs struct {
+	write func(v2btypes.FrameData) error
+	close func() error
+}
+
+// Frames 返回每帧调用 write、结束时调用 close 的 Emitter，close 可为 nil。
+// 用于 json2ass、json2svg、json2lottie 等自行处理第一帧的编码器
+func Frames(write func(v2btypes.FrameData) error, close func() error) Emitter {
+	return frameFuncs{write: write, close: close}
+}
+
+func (f frameFuncs) Begin(v2btypes.FrameData) error { return nil }
+
+func (f frameFuncs) Frame(fd v2btypes.FrameData) error { return f.write(fd) }
+
+func (f frameFuncs) End() error {
+	if f.close == nil {
+		return nil
+	}
+	return f.close()
+}
@@ -0,0 +1,36 @@
+package emit
+
+import (
+	"io"
+	"video2bas/bas2xml"
+	v2btypes "video2bas/type"
+)
+
+// XML 将 BAS 分块写为哔哩哔哩弹幕 XML，需作为 ChunkHandler 交给 BAS
+type XML struct {
+	w *bas2xml.Writer
+}
+
+// NewXML 创建写入 w 的 XML 输出
+func NewXML(w io.Writer, opts bas2xml.Options) *XML {
+	return &XML{w: bas2xml.NewWriter(w, opts)}
+}
+
+func (x *XML) Begin(v2btypes.FrameData) error { return nil }
+
+func (x *XML) Frame(v2btypes.FrameData) error { return nil }
+
+// Chunk 写出一个分块，发送时间为 SendTime 加上分块的起点
+func (x *XML) Chunk(c Chunk) error {
+	return x.w.WriteChunk(c.Code, float64(c.Start)/1000)
+}
+
+// End 写出 XML 结尾
+func (x *XML) End() error {
+	return x.w.Close()
+}
+
+// Chunks 返回已写出的分块数
+func (x *XML) Chunks() int {
+	return x.w.Chunks()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"video2bas/basgen"
+	"video2bas/emit"
+	"video2bas/json2ass"
+	"video2bas/json2bas"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+// outputFlags 注册主命令与 import 子命令共用的帧率、输出、生成器、样式与坐标参数
+type outputFlags struct {
+	fps         *string
+	output      *string
+	format      *string
+	maxFileSize *int
+	precision   *int
+	strategy    *string
+	delta       *bool
+	tween       *bool
+	start       *float64
+	idMap       *string
+	framesOut   *string
+	ass         *string
+	playRes     *string
+	xml         *string
+	xmlOnly     *bool
+	svg         *string
+	html        *string
+	lottie      *string
+	styles      *styleFlags
+	coords      *coordFlags
+}
+
+// addOutputFlags 注册共用参数，output 为默认输出前缀，scale 与 unit 为源坐标的默认缩放及其单位名，style 为默认样式
+func addOutputFlags(fs *flag.FlagSet, output string, scale float64, unit string, style json2bas.Style) *outputFlags {
+	return &outputFlags{
+		fps:         fs.String("fps", "10", "帧率，可为整数、小数或分数（如 30000/1001）"),
+		output:      fs.String("output", output, "输出文件路径"),
+		format:      fs.String("format", emit.FormatBAS, "输出格式，逗号分隔：bas（.bas.txt 分块）、xml、svg、html、ass、lottie、jsonl，文件名为 -output 加扩展名"),
+		maxFileSize: fs.Int("maxsize", 2*1024*1024, "单个输出文件最大尺寸，单位字节"),
+		precision:   fs.Int("precision", 0, "路径坐标保留的小数位数（viewBox 单位，见 -scale）"),
+		strategy:    fs.String("strategy", json2bas.StrategyFrame, "输出策略：frame（每帧新建对象）或 pool（每个图层槽位复用一个对象）"),
+		delta:       fs.Bool("delta", false, "与上一帧同一图层槽位相同的图层只延长显示时间，不再重复输出"),
+		tween:       fs.Bool("tween", false, "与上一帧同一图层槽位路径命令序列相同的图层以补间动画过渡，可用较低帧率得到平滑运动"),
+		start:       fs.Float64("start", 0, "动画在视频中的起始时间（毫秒），即 .bas.txt 分块的发送时间；XML 中每个分块的发送时间为该时间加上分块第一帧的时间"),
+		idMap:       fs.String("idmap", "", "将短标识符与帧号、颜色的对应关系写入该文件，便于调试"),
+		framesOut:   fs.String("frames-out", "", "将中间帧数据以 JSONL 格式写入该文件"),
+		ass:         fs.String("ass", "", "同时输出 ASS 字幕文件（\\p 绘图），供 mpv/VLC 等本地播放器使用"),
+		playRes:     fs.String("playres", "1920x1080", "ASS 字幕的 PlayRes 分辨率 WxH"),
+		xml:         fs.String("xml", "", "将各分块作为高级弹幕（mode 9）写入该哔哩哔哩弹幕 XML 文件"),
+		xmlOnly:     fs.Bool("xmlonly", false, "已弃用，等同于 -format 中以 xml 代替 bas：只输出 XML，不写出 .bas.txt 分块"),
+		svg:         fs.String("svg", "", "同时输出 SMIL 动画 SVG，可在浏览器中预览"),
+		html:        fs.String("html", "", "同时输出 HTML 预览页，将动画叠加在源视频上并提供时间滑块"),
+		lottie:      fs.String("lottie", "", "同时输出 Lottie（bodymovin）JSON，拓扑一致的相邻帧合并为路径关键帧"),
+		styles:      addStyleFlags(fs, style),
+		coords:      addCoordFlags(fs, scale, unit),
+	}
+}
+
+// options 检查参数并返回填好输出相关字段的 pipelineOptions
+func (f *outputFlags) options() (pipelineOptions, error) {
+	rate, err := v2btypes.ParseRate(*f.fps)
+	if err != nil {
+		return pipelineOptions{}, err
+	}
+	if *f.precision < 0 || *f.precision > 6 {
+		return pipelineOptions{}, fmt.Errorf("precision out of range: %d", *f.precision)
+	}
+	if *f.start < 0 {
+		return pipelineOptions{}, fmt.Errorf("start out of range: %v", *f.start)
+	}
+	if _, err := json2bas.NewGenerator(*f.strategy, json2bas.Options{}); err != nil {
+		return pipelineOptions{}, err
+	}
+	assOpts := json2ass.DefaultOptions()
+	if assOpts.PlayResX, assOpts.PlayResY, err = json2ass.ParsePlayRes(*f.playRes); err != nil {
+		return pipelineOptions{}, err
+	}
+	formats, err := emit.ParseFormats(*f.format)
+	if err != nil {
+		return pipelineOptions{}, err
+	}
+	if *f.xmlOnly {
+		log.Println("-xmlonly is deprecated, use -format xml")
+		formats = xmlOnlyFormats(formats)
+	}
+	style, err := f.styles.Style()
+	if err != nil {
+		return pipelineOptions{}, err
+	}
+	coords, err := f.coords.Coords()
+	if err != nil {
+		return pipelineOptions{}, err
+	}
+	player, err := f.coords.Player()
+	if err != nil {
+		return pipelineOptions{}, err
+	}
+	return pipelineOptions{
+		FPS:         rate,
+		OutputPath:  *f.output,
+		MaxFileSize: *f.maxFileSize,
+		Path:        pathdata.Options{Precision: *f.precision},
+		Formats:     formats,
+		FramesOut:   *f.framesOut,
+		Strategy:    *f.strategy,
+		Delta:       *f.delta,
+		Tween:       *f.tween,
+		Coords:      coords,
+		Player:      player,
+		Style:       style,
+		IDs:         basgen.NewIDAllocator(*f.idMap != ""),
+		IDMap:       *f.idMap,
+		ASSOut:      *f.ass,
+		ASS:         assOpts,
+		SVGOut:      *f.svg,
+		HTMLOut:     *f.html,
+		LottieOut:   *f.lottie,
+		StartTime:   *f.start,
+		XMLOut:      *f.xml,
+		XML:         xmlOptions(),
+	}, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"video2bas/emit"
+	"video2bas/svg2json"
+)
+
+// videoSrc 返回 HTML 预览页中引用视频的地址，尽量使用相对 HTML 文件的路径
+func videoSrc(htmlPath, videoPath string) string {
+	if videoPath == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(videoPath)
+	if err != nil {
+		return filepath.ToSlash(videoPath)
+	}
+	if dir, err := filepath.Abs(filepath.Dir(htmlPath)); err == nil {
+		if rel, err := filepath.Rel(dir, abs); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return "file://" + filepath.ToSlash(abs)
+}
+
+// sameFile 判断两个路径是否指向同一文件
+func sameFile(a, b string) bool {
+	ia, err1 := os.Stat(a)
+	ib, err2 := os.Stat(b)
+	return err1 == nil && err2 == nil && os.SameFile(ia, ib)
+}
+
+// generateBasFromFrames 读取 JSONL 格式的 FrameData 直接生成 BAS，跳过视频处理
+func generateBasFromFrames(opts pipelineOptions) {
+	file, err := os.Open(opts.FramesIn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	dec := svg2json.NewFrameDecoder(file)
+	// 不覆盖正在读取的 JSONL
+	if path, ok := opts.outputPaths()[emit.FormatJSONL]; ok && sameFile(path, opts.FramesIn) {
+		log.Fatalf("%s: refusing to overwrite the input frame data", path)
+	}
+	out := newOutputs(opts)
+	count := 0
+	for {
+		fd, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("%s: %v", opts.FramesIn, err)
+		}
+		out.Frame(fd)
+		count++
+	}
+	out.Close()
+	log.Printf("Generated BAS code from %d frames", count)
+}
@@ -3,14 +3,13 @@ module video2bas
 go 1.24
 
 require (
-	github.com/ajstarks/svgo v0.0.0-20211024235047-1546f124cd8b // indirect
+	github.com/gotranspile/gotrace v0.0.0-20230726133510-8c9665a39b09
+	github.com/u2takey/ffmpeg-go v0.5.0
+)
+
+require (
 	github.com/aws/aws-sdk-go v1.55.8 // indirect
 	github.com/gotranspile/cxgo v0.5.2 // indirect
-	github.com/gotranspile/gotrace v0.0.0-20230726133510-8c9665a39b09 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
-	github.com/rustyoz/Mtransform v0.0.0-20250628105438-00796a985d0a // indirect
-	github.com/rustyoz/genericlexer v0.0.0-20250522144106-d3cfee480384 // indirect
-	github.com/rustyoz/svg v0.0.0-20250705135709-8b1786137cb3 // indirect
-	github.com/u2takey/ffmpeg-go v0.5.0 // indirect
 	github.com/u2takey/go-utils v0.3.1 // indirect
 )
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"video2bas/json2bas"
+	"video2bas/svg2json"
+)
+
+// runImport 处理 import 子命令：读取按编号命名的 SVG 帧目录直接生成 BAS，跳过视频分层与描摹
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	timingPath := fs.String("timing", "", "时间轴文件，每行为 \"<文件名> <开始毫秒> [结束毫秒]\"，未提供时按 -fps 排布各帧")
+	// 外部绘制的 SVG 没有描摹在相邻颜色之间留下的缝隙，默认不描边
+	style := json2bas.DefaultStyle()
+	style.BorderWidth = 0
+	shared := addOutputFlags(fs, "output/import", 1, "个 SVG 单位", style)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: video2bas import [flags] <svg dir>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	opts, err := shared.options()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// 外部绘制的 SVG 中黑色是正常的填充色，不视为背景
+	opts.KeepBlack = true
+
+	files, err := svg2json.ReadSVGDir(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var timings map[string]svg2json.FrameTiming
+	if *timingPath != "" {
+		if timings, err = svg2json.ReadTimingFile(*timingPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	resolved, err := svg2json.ResolveTimings(files, timings, opts.FPS)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Importing %d SVG frames from %s", len(files), fs.Arg(0))
+
+	out := newOutputs(opts)
+	blank := 0
+	for i, f := range files {
+		fd, err := svg2json.ImportFrameWithOptions(i, f.Data, resolved[i], opts.Coords)
+		if err != nil {
+			log.Fatalf("%s: %v", f.Name, err)
+		}
+		if len(fd.Layers) == 0 {
+			blank++
+		}
+		out.Frame(fd)
+	}
+	out.Close()
+	if blank > 0 {
+		log.Printf("%d blank frames without drawable shapes", blank)
+	}
+	writeIDMap(opts)
+}
@@ -0,0 +1,202 @@
+package json2ass
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"video2bas/json2bas"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+// Options 控制 ASS 输出
+type Options struct {
+	PlayResX, PlayResY int
+	Rate               v2btypes.Rate
+	Scale              int             // 绘图精度等级 \p<Scale>，坐标放大 2^(Scale-1) 倍，1 为整像素
+	KeepBlack          bool            // 保留黑色图层；视频转换时黑色视为背景跳过
+	Fit                v2btypes.Fit    // viewBox 适配到 PlayRes 的方式，为空时等比居中
+	Style              *json2bas.Style // 颜色替换、跳过、不透明度与描边，与 BAS 输出相同；为 nil 时使用 json2bas.DefaultStyle
+	StartTime          float64         // 动画在视频中的起始时间（毫秒），加到每个事件的时间上
+	Title              string
+}
+
+// DefaultOptions 返回 1920x1080、整像素精度的设置
+func DefaultOptions() Options {
+	return Options{PlayResX: 1920, PlayResY: 1080, Rate: v2btypes.Rate{Num: 10, Den: 1}, Scale: 1}
+}
+
+// ParsePlayRes 解析 "1920x1080" 形式的分辨率
+func ParsePlayRes(s string) (int, int, error) {
+	ws, hs, ok := strings.Cut(strings.ToLower(s), "x")
+	w, err1 := strconv.Atoi(ws)
+	h, err2 := strconv.Atoi(hs)
+	if !ok || err1 != nil || err2 != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("invalid PlayRes %q, expected WxH", s)
+	}
+	return w, h, nil
+}
+
+// Writer 逐帧写出 ASS 字幕，每个图层为一条 \p 绘图事件
+type Writer struct {
+	w      *bufio.Writer
+	opts   Options
+	style  json2bas.Style
+	header bool
+	events int
+}
+
+// NewWriter 创建写入 w 的 ASS 编码器
+func NewWriter(w io.Writer, opts Options) *Writer {
+	if opts.Scale < 1 {
+		opts.Scale = 1
+	}
+	style := json2bas.DefaultStyle()
+	if opts.Style != nil {
+		style = *opts.Style
+	}
+	return &Writer{w: bufio.NewWriter(w), opts: opts, style: style}
+}
+
+func (w *Writer) writeHeader() {
+	title := w.opts.Title
+	if title == "" {
+		title = "video2bas"
+	}
+	fmt.Fprintf(w.w, `[Script Info]
+Title: %s
+ScriptType: v4.00+
+PlayResX: %d
+PlayResY: %d
+WrapStyle: 2
+ScaledBorderAndShadow: yes
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,20,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,0,0,7,0,0,0,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+`, title, w.opts.PlayResX, w.opts.PlayResY)
+	w.header = true
+}
+
+// WriteFrame 写出一帧的全部图层，按 Z 从下到上分配事件层级；描边宽度按 viewBox 到 PlayRes 的缩放换算，
+// 与 BAS 的描边一样一半位于图形之外
+func (w *Writer) WriteFrame(fd v2btypes.FrameData) error {
+	if !w.header {
+		w.writeHeader()
+	}
+	layers := append([]v2btypes.Layer(nil), fd.Layers...)
+	sort.SliceStable(layers, func(i, j int) bool { return layers[i].Z < layers[j].Z })
+
+	toPlayRes := w.fit(fd.ViewBox)
+	o, dx, dy := toPlayRes(v2btypes.Point{}), toPlayRes(v2btypes.Point{X: 1}), toPlayRes(v2btypes.Point{Y: 1})
+	scale := math.Sqrt((dx.X - o.X) * (dy.Y - o.Y))
+	rank := 0
+	for _, l := range layers {
+		if l.Color == "000000" && !w.opts.KeepBlack {
+			continue
+		}
+		if _, err := v2btypes.ParseHexColor(l.Color); err != nil {
+			return fmt.Errorf("frame %d: %w", fd.FrameIndex, err)
+		}
+		ls := w.style.Resolve(l.Color)
+		if ls.Skip {
+			continue
+		}
+		path, err := layerPath(l)
+		if err != nil {
+			return fmt.Errorf("frame %d: %w", fd.FrameIndex, err)
+		}
+		drawing := w.drawing(path.Transform(toPlayRes))
+		if drawing == "" {
+			continue
+		}
+		start, end := w.opts.Rate.FrameStart(fd.FrameIndex), w.opts.Rate.FrameEnd(fd.FrameIndex)
+		if l.HasTiming() {
+			start, end = l.Start, l.End
+		}
+		offset := int64(math.Round(w.opts.StartTime))
+		alpha := 255 - int(math.Round(math.Max(0, math.Min(1, l.Alpha*ls.Opacity))*255))
+		bord := strconv.FormatFloat(math.Round(ls.BorderWidth/2*scale*100)/100, 'f', -1, 64)
+		f, b := ls.Fill, ls.Border
+		fmt.Fprintf(w.w, "Dialogue: %d,%s,%s,Default,,0,0,0,,{\\an7\\pos(0,0)\\bord%s\\shad0\\1c&H%02X%02X%02X&\\3c&H%02X%02X%02X&\\alpha&H%02X&\\p%d}%s{\\p0}\n",
+			rank, timestamp(offset+start), timestamp(offset+end), bord, f.B, f.G, f.R, b.B, b.G, b.R, alpha, w.opts.Scale, drawing)
+		rank++
+		w.events++
+	}
+	return nil
+}
+
+// Close 刷新缓冲区
+func (w *Writer) Close() error {
+	if !w.header {
+		w.writeHeader()
+	}
+	return w.w.Flush()
+}
+
+// Events 返回已写出的事件数
+func (w *Writer) Events() int {
+	return w.events
+}
+
+func layerPath(l v2btypes.Layer) (v2btypes.Path, error) {
+	if l.Path != nil {
+		return *l.Path, nil
+	}
+	return pathdata.Parse(l.PathData)
+}
+
+// fit 返回将 viewBox 按 Options.Fit 适配到 PlayRes 的坐标变换
+func (w *Writer) fit(vb v2btypes.ViewBox) func(v2btypes.Point) v2btypes.Point {
+	playRes := v2btypes.ViewBox{W: float64(w.opts.PlayResX), H: float64(w.opts.PlayResY)}
+	return w.opts.Fit.Transform(vb, playRes)
+}
+
+// drawing 输出 ASS 绘图命令：m 开始子路径，l 直线，b 三次贝塞尔，子路径自动闭合
+func (w *Writer) drawing(p v2btypes.Path) string {
+	k := math.Pow(2, float64(w.opts.Scale-1))
+	coord := func(v float64) string {
+		return strconv.FormatInt(int64(math.Round(v*k)), 10)
+	}
+	var sb strings.Builder
+	for _, sp := range p.SubPaths {
+		if len(sp.Segments) == 0 {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString("m " + coord(sp.Start.X) + " " + coord(sp.Start.Y))
+		last := byte(0)
+		for _, seg := range sp.Segments {
+			cmd, n := byte('l'), 1
+			if seg.Kind == v2btypes.SegCubic {
+				cmd, n = 'b', 3
+			}
+			if cmd != last {
+				sb.WriteString(" " + string(cmd))
+				last = cmd
+			}
+			for i := 0; i < n; i++ {
+				sb.WriteString(" " + coord(seg.Pts[i].X) + " " + coord(seg.Pts[i].Y))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// timestamp 将毫秒格式化为 H:MM:SS.cc，四舍五入到百分之一秒
+func timestamp(ms int64) string {
+	if ms < 0 {
+		ms = 0
+	}
+	cs := (ms + 5) / 10
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
@@ -0,0 +1,97 @@
+package json2ass
+
+import (
+	"strings"
+	"testing"
+	"video2bas/json2bas"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+func writeASS(t *testing.T, opts Options, frames ...v2btypes.FrameData) []string {
+	t.Helper()
+	var sb strings.Builder
+	w := NewWriter(&sb, opts)
+	for _, fd := range frames {
+		if err := w.WriteFrame(fd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if strings.HasPrefix(line, "Dialogue: ") {
+			events = append(events, line)
+		}
+	}
+	return events
+}
+
+func square(t *testing.T) *v2btypes.Path {
+	t.Helper()
+	p, err := pathdata.Parse("M0 0H100V100H0Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &p
+}
+
+// viewBox 按 Fit 适配到 PlayRes：letterbox 居中留边，fill 铺满后裁去超出部分，stretch 宽高分别缩放
+func TestWriteFrameFit(t *testing.T) {
+	fd := v2btypes.FrameData{
+		ViewBox: v2btypes.ViewBox{W: 100, H: 100},
+		Layers:  []v2btypes.Layer{{Color: "FFFFFF", Alpha: 1, Path: square(t)}},
+	}
+	for _, tc := range []struct {
+		fit     v2btypes.Fit
+		drawing string
+	}{
+		{"", "m 50 0 l 150 0 150 100 50 100"},
+		{v2btypes.FitLetterbox, "m 50 0 l 150 0 150 100 50 100"},
+		{v2btypes.FitFill, "m 0 -50 l 200 -50 200 150 0 150"},
+		{v2btypes.FitStretch, "m 0 0 l 200 0 200 100 0 100"},
+	} {
+		style := json2bas.DefaultStyle()
+		style.BorderWidth = 0
+		events := writeASS(t, Options{PlayResX: 200, PlayResY: 100, Rate: v2btypes.NewRate(10, 1), Fit: tc.fit, Style: &style}, fd)
+		if len(events) != 1 || !strings.Contains(events[0], "}"+tc.drawing+"{") {
+			t.Errorf("fit %q: got %q, want drawing %q", tc.fit, events, tc.drawing)
+		}
+	}
+}
+
+// 样式中的跳过、颜色替换、不透明度与描边和 BAS 输出一致，-start 加到每个事件的时间上
+func TestWriteFrameStyle(t *testing.T) {
+	half := 0.5
+	style := json2bas.DefaultStyle()
+	style.BorderWidth = 2
+	style.BorderColor = "102030"
+	style.Colors = map[string]json2bas.ColorStyle{
+		"FF0000": {Skip: true},
+		"00FF00": {Fill: "0000FF", Opacity: &half},
+	}
+	fd := v2btypes.FrameData{
+		FrameIndex: 1,
+		ViewBox:    v2btypes.ViewBox{W: 100, H: 100},
+		Layers: []v2btypes.Layer{
+			{Color: "FF0000", Z: 0, Alpha: 1, Path: square(t)},
+			{Color: "00FF00", Z: 1, Alpha: 1, Path: square(t)},
+			{Color: "FFFFFF", Z: 2, Alpha: 1, Path: square(t), Start: 500, End: 750},
+		},
+	}
+	events := writeASS(t, Options{PlayResX: 200, PlayResY: 200, Rate: v2btypes.NewRate(10, 1), Style: &style, StartTime: 1000}, fd)
+	want := []string{
+		`Dialogue: 0,0:00:01.10,0:00:01.20,Default,,0,0,0,,{\an7\pos(0,0)\bord2\shad0\1c&HFF0000&\3c&H302010&\alpha&H7F&\p1}`,
+		`Dialogue: 1,0:00:01.50,0:00:01.75,Default,,0,0,0,,{\an7\pos(0,0)\bord2\shad0\1c&HFFFFFF&\3c&H302010&\alpha&H00&\p1}`,
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d:\n%s", len(events), len(want), strings.Join(events, "\n"))
+	}
+	for i, e := range events {
+		if !strings.HasPrefix(e, want[i]) {
+			t.Errorf("event %d:\n got %s\nwant %s...", i, e, want[i])
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package json2bas
+
+import (
+	"fmt"
+	v2btypes "video2bas/type"
+)
+
+// 输出策略
+const (
+	StrategyFrame = "frame" // 每帧每个图层声明新对象
+	StrategyPool  = "pool"  // 每个图层槽位复用一个长期存在的对象
+)
+
+// Generator 按顺序逐帧生成 BAS 代码，可在帧之间保持状态
+type Generator interface {
+	// Frame 生成一帧的代码
+	Frame(frame v2btypes.FrameData) (string, Stats)
+	// Tail 返回结束当前分块所需的收尾代码，不改变状态
+	Tail() string
+	// Reset 丢弃全部状态以及最近一次 Frame 分配的标识符，并以 startTime（毫秒）作为新分块的时间轴起点；
+	// 该帧随后在新的分块中重新生成
+	Reset(startTime float64)
+}
+
+// NewGenerator 按策略名创建生成器
+func NewGenerator(strategy string, opts Options) (Generator, error) {
+	switch strategy {
+	case "", StrategyFrame:
+		if opts.Delta || opts.Tween {
+			return newDeltaFrameGenerator(opts), nil
+		}
+		return &frameGenerator{opts: opts}, nil
+	case StrategyPool:
+		return NewPoolGenerator(opts), nil
+	default:
+		return nil, fmt.Errorf("unknown strategy %q", strategy)
+	}
+}
+
+// frameGenerator 逐帧调用 GenerateFrame，只记录最近一帧的标识符分配位置
+type frameGenerator struct {
+	opts Options
+	mark int
+}
+
+func (g *frameGenerator) Frame(frame v2btypes.FrameData) (string, Stats) {
+	if g.opts.IDs != nil {
+		g.mark = g.opts.IDs.Mark()
+	}
+	return GenerateFrame(frame, g.opts)
+}
+
+func (*frameGenerator) Tail() string { return "" }
+
+func (g *frameGenerator) Reset(startTime float64) {
+	if g.opts.IDs != nil {
+		g.opts.IDs.Rollback(g.mark)
+	}
+	g.opts.StartTime = startTime
+}
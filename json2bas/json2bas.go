@@ -3,129 +3,103 @@ package json2bas
 import (
 	"fmt"
 	"math"
-	"regexp"
-	"strconv"
-	"strings"
+	"sort"
 	"sync"
+	"video2bas/basgen"
+	"video2bas/pathdata"
 	v2btypes "video2bas/type"
 )
 
-const (
-	DefaultViewBoxW = 4000
-	DefaultViewBoxH = 3620
-)
+// Options 控制 BAS 代码生成
+type Options struct {
+	ViewBox   v2btypes.ViewBox // 对象的 viewBox，与帧数据的坐标空间一致
+	Fit       v2btypes.Fit     // 样式未设置尺寸与位置时对象在播放器中的适配方式，见 Options.style
+	Player    float64          // 播放器画面的宽高比，0 为 16:9
+	Rate      v2btypes.Rate    // 帧率，决定每帧的显示区间
+	StartTime float64          // 分块的时间轴起点（毫秒），从各时间中减去，早于起点的时间按起点计
+	Path      pathdata.Options
+	KeepBlack bool                // 保留黑色图层；视频转换时黑色视为背景跳过
+	Style     *Style              // 对象样式，为 nil 时使用 DefaultStyle
+	IDs       *basgen.IDAllocator // 为对象分配短标识符，为 nil 时使用 p<帧号>_<颜色> 形式的名称
+	Delta     bool                // 与上一帧同一槽位相同的图层只延长显示区间，需逐帧顺序生成
+	Tween     bool                // 与上一帧同一槽位拓扑相同的图层以补间过渡，路径只使用绝对命令
+}
 
-// FlipSvgPath 类似 JS flipSvgPath
-func FlipSvgPath(d string, viewBoxH int) string {
-	if viewBoxH == 0 {
-		viewBoxH = DefaultViewBoxH
+// span 返回图层相对 StartTime 的显示区间（毫秒），不会为负。
+// 各帧的区间取自精确的有理数时间轴，相邻帧首尾相接，累计误差不超过 1ms；图层自带时间时以其为准
+func (opts Options) span(frameIndex int, layer v2btypes.Layer) (start, end int64) {
+	start, end = opts.Rate.FrameStart(frameIndex), opts.Rate.FrameEnd(frameIndex)
+	if layer.HasTiming() {
+		start, end = layer.Start, layer.End
 	}
+	start = max(int64(math.Floor(float64(start)-opts.StartTime)), 0)
+	end = max(int64(math.Floor(float64(end)-opts.StartTime)), start)
+	return start, end
+}
 
-	re := regexp.MustCompile(`-?[0-9]*\.?[0-9]+(?:e[-+]?\d+)?|[MLHVCSQTAZmlhvcsqtaz]|[\s,]+`)
-	tokens := re.FindAllString(d, -1)
-
-	var output []string
-	var command string
-	var params []float64
-
-	processGroup := func(group []float64, isAbs bool) []float64 {
-		if len(group) == 0 {
-			return group
-		}
-		switch command {
-		case "V":
-			return []float64{float64(viewBoxH) - group[0]}
-		case "v":
-			return []float64{-group[0]}
-		case "A":
-			return []float64{group[0], group[1], group[2], group[3], group[4], group[5], float64(viewBoxH) - group[6]}
-		case "a":
-			return []float64{group[0], group[1], group[2], group[3], group[4], group[5], -group[6]}
-		default:
-			res := make([]float64, len(group))
-			for i, val := range group {
-				if i%2 == 1 {
-					if isAbs {
-						res[i] = float64(viewBoxH) - val
-					} else {
-						res[i] = -val
-					}
-				} else {
-					res[i] = val
-				}
-			}
-			return res
+// FrameStart 返回帧中最早的显示时间（毫秒，不减去 StartTime），用作从该帧开始的分块的时间轴起点
+func (opts Options) FrameStart(frame v2btypes.FrameData) int64 {
+	start := opts.Rate.FrameStart(frame.FrameIndex)
+	timed := false
+	for _, l := range frame.Layers {
+		if l.HasTiming() && (!timed || l.Start < start) {
+			start, timed = l.Start, true
 		}
 	}
+	return start
+}
 
-	getGroupSize := func(cmd string) int {
-		switch strings.ToUpper(cmd) {
-		case "H", "h", "V", "v":
-			return 1
-		case "M", "m", "L", "l", "T", "t":
-			return 2
-		case "S", "s", "Q", "q":
-			return 4
-		case "C", "c":
-			return 6
-		case "A", "a":
-			return 7
-		case "Z", "z":
-			return 0
-		default:
-			return 0
-		}
-	}
+// Stats 记录生成过程中的统计信息
+type Stats struct {
+	Layers       int // 输出的图层对象数
+	PathBytes    int // 压缩后的路径数据字节数
+	RawPathBytes int // 同精度下未压缩（绝对坐标、完整分隔符）的路径数据字节数
+	Skipped      int // 各阶段跳过的空图层数
+	Reused       int // 增量模式下与上一帧相同、只延长显示的图层数
+	Tweened      int // 补间模式下由上一帧过渡得到的图层数
+}
 
-	for _, token := range tokens {
-		t := strings.TrimSpace(token)
-		if t == "" || t == "," {
-			continue
-		}
-		if match, _ := regexp.MatchString(`^[MLHVCSQTAZmlhvcsqtaz]$`, t); match {
-			if len(params) > 0 {
-				groupSize := getGroupSize(command)
-				for i := 0; i < len(params); i += groupSize {
-					group := params[i:min(i+groupSize, len(params))]
-					processed := processGroup(group, command == strings.ToUpper(command))
-					strs := make([]string, len(processed))
-					for j, v := range processed {
-						strs[j] = strconv.FormatFloat(v, 'f', -1, 64)
-					}
-					output = append(output, strings.Join(strs, " "))
-				}
-				params = nil
-			}
-			command = t
-			output = append(output, t)
-		} else {
-			num, _ := strconv.ParseFloat(t, 64)
-			params = append(params, num)
-		}
-	}
-	if len(params) > 0 {
-		groupSize := getGroupSize(command)
-		for i := 0; i < len(params); i += groupSize {
-			group := params[i:min(i+groupSize, len(params))]
-			processed := processGroup(group, command == strings.ToUpper(command))
-			strs := make([]string, len(processed))
-			for j, v := range processed {
-				strs[j] = strconv.FormatFloat(v, 'f', -1, 64)
-			}
-			output = append(output, strings.Join(strs, " "))
-		}
+// Add 累加另一份统计
+func (s *Stats) Add(o Stats) {
+	s.Layers += o.Layers
+	s.PathBytes += o.PathBytes
+	s.RawPathBytes += o.RawPathBytes
+	s.Skipped += o.Skipped
+	s.Reused += o.Reused
+	s.Tweened += o.Tweened
+}
+
+// Saved 返回路径压缩节省的字节数
+func (s Stats) Saved() int {
+	return s.RawPathBytes - s.PathBytes
+}
+
+// GenerateAllWithOptions 并发生成所有帧，返回每帧的 BAS 文本与统计
+func GenerateAllWithOptions(frames []v2btypes.FrameData, opts Options, parallel int) ([]string, []Stats) {
+	built := BuildAll(frames, opts, parallel)
+	results := make([]string, len(built))
+	stats := make([]Stats, len(built))
+	for i, b := range built {
+		results[i], stats[i] = b.Format(opts.IDs)
 	}
+	return results, stats
+}
 
-	return strings.Join(output, " ")
+// BuiltFrame 为已生成、尚未分配标识符的单帧
+type BuiltFrame struct {
+	frameIndex int
+	r          frameResult
 }
 
-func GenerateAllBasText(frames []v2btypes.FrameData, viewBoxW, viewBoxH int, framerate, startTime float64) []string {
-	return GenerateAllBasTextWithParallel(frames, viewBoxW, viewBoxH, framerate, startTime, 4)
+// Format 用 ids 分配标识符并返回 BAS 文本与统计，ids 为 nil 时使用完整名称。
+// 标识符按调用顺序分配，须按帧顺序调用
+func (b BuiltFrame) Format(ids *basgen.IDAllocator) (string, Stats) {
+	return basgen.Format(b.r.assignIDs(ids, b.frameIndex)...), b.r.stats
 }
 
-// GenerateAllBasTextWithParallel 支持并发上限
-func GenerateAllBasTextWithParallel(frames []v2btypes.FrameData, viewBoxW, viewBoxH int, framerate, startTime float64, parallel int) []string {
-	results := make([]string, len(frames))
+// BuildAll 并发生成所有帧，标识符留待 Format 时按帧顺序分配，保证输出确定
+func BuildAll(frames []v2btypes.FrameData, opts Options, parallel int) []BuiltFrame {
+	built := make([]BuiltFrame, len(frames))
 	var wg sync.WaitGroup
 	if parallel <= 0 {
 		parallel = 1
@@ -137,45 +111,125 @@ func GenerateAllBasTextWithParallel(frames []v2btypes.FrameData, viewBoxW, viewB
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
-			bas := GenerateBasText(frame, viewBoxW, viewBoxH, framerate, startTime)
-			results[idx] = bas
+			built[idx] = BuiltFrame{frameIndex: frame.FrameIndex, r: buildFrame(frame, opts)}
 		}(i, f)
 	}
 	wg.Wait()
-	return results
+	return built
+}
+
+type basLayer struct {
+	v2btypes.Layer
+	path v2btypes.Path
+	raw  string // 无法解析的文本路径数据，原样输出
+}
+
+// frameLayers 按 Z 从下到上整理帧内的图层，缺少几何数据时解析 PathData
+func frameLayers(frame v2btypes.FrameData) []basLayer {
+	layers := make([]basLayer, 0, len(frame.Layers))
+	for _, l := range frame.Layers {
+		bl := basLayer{Layer: l}
+		if l.Path != nil {
+			bl.path = *l.Path
+		} else if path, err := pathdata.Parse(l.PathData); err == nil {
+			bl.path = path
+		} else {
+			bl.raw = l.PathData
+		}
+		layers = append(layers, bl)
+	}
+	sort.SliceStable(layers, func(i, j int) bool { return layers[i].Z < layers[j].Z })
+	return layers
+}
+
+// GenerateFrame 按 opts 生成单帧 BAS 代码，并返回统计
+func GenerateFrame(frame v2btypes.FrameData, opts Options) (string, Stats) {
+	stmts, stats := FrameStatements(frame, opts)
+	return basgen.Format(stmts...), stats
+}
+
+// FrameStatements 生成单帧的 BAS 语句：每个图层定义一个 path 对象，在帧开始时显示、帧结束时隐藏
+func FrameStatements(frame v2btypes.FrameData, opts Options) ([]basgen.Statement, Stats) {
+	r := buildFrame(frame, opts)
+	return r.assignIDs(opts.IDs, frame.FrameIndex), r.stats
+}
+
+// frameResult 为使用完整名称生成的单帧语句，以及各对象的名称与颜色
+type frameResult struct {
+	stmts   []basgen.Statement
+	objects []basgen.IDEntry
+	stats   Stats
+}
+
+// assignIDs 用 ids 将对象名替换为短标识符，ids 为 nil 时原样返回
+func (r frameResult) assignIDs(ids *basgen.IDAllocator, frameIndex int) []basgen.Statement {
+	if ids == nil {
+		return r.stmts
+	}
+	names := make(map[string]string, len(r.objects))
+	for _, o := range r.objects {
+		o.Frame = frameIndex
+		names[o.Name] = ids.Next(o)
+	}
+	return basgen.Rename(r.stmts, names)
 }
 
-// GenerateBasText 输入 FrameData 输出封装后的字符串
-func GenerateBasText(frame v2btypes.FrameData, viewBoxW, viewBoxH int, framerate, startTime float64) string {
-	var out strings.Builder
+func buildFrame(frame v2btypes.FrameData, opts Options) frameResult {
+	var stmts []basgen.Statement
+	var objects []basgen.IDEntry
+	stats := Stats{Skipped: frame.Skipped}
+	viewBox := opts.ViewBox.String()
+	style := opts.style()
+	names := make(map[string]int)
 
-	for _, layer := range frame.Data {
-		color := layer["color"]
-		if color == "000000" {
+	for _, layer := range frameLayers(frame) {
+		hex := layer.Color
+		if hex == "000000" && !opts.KeepBlack {
+			continue
+		}
+		if _, err := v2btypes.ParseHexColor(hex); err != nil {
+			stats.Skipped++
+			continue
+		}
+		ls := style.resolve(hex)
+		if ls.skip {
 			continue
 		}
-		pathData := FlipSvgPath(layer["pathdata"], viewBoxH)
+		pathData := layer.raw
+		raw := len(pathData)
+		if pathData == "" {
+			pathData = pathdata.Format(layer.path, opts.Path)
+			raw = len(layer.path.SVG(opts.Path.Precision))
+		}
+		if pathData == "" {
+			stats.Skipped++
+			continue
+		}
+		stats.RawPathBytes += raw
+		stats.PathBytes += len(pathData)
+		stats.Layers++
 		frameNum := frame.FrameIndex
-		name := fmt.Sprintf("%d_%s", frameNum, color)
-		displayTime := 1000.0 / framerate
-		startOffset := float64(frameNum)/framerate*1000.0 - startTime
-
-		out.WriteString(fmt.Sprintf(`
-let p%s = path{d = "%s" viewBox="0 0 %d %d" width = 100%% fillColor = 0x%s alpha = 0
-borderWidth = 15
-    borderColor = 0x%s
-}
-set p%s {} %dms
-then set p%s {alpha = 1} %dms
-then set p%s {} %dms
-then set p%s {alpha = 0} %dms
-`, name, pathData, viewBoxW, viewBoxH, color, color,
-			name, int(math.Floor(startOffset)),
-			name, int(math.Floor(displayTime*0)),
-			name, int(math.Floor(displayTime)),
-			name, int(math.Floor(displayTime*0)),
-		))
+		name := fmt.Sprintf("p%d_%s", frameNum, hex)
+		// 同色图层不相邻时会出现多次，追加序号避免重名
+		if n := names[name]; n > 0 {
+			names[name]++
+			name = fmt.Sprintf("%s_%d", name, n)
+		} else {
+			names[name] = 1
+		}
+		start, end := opts.span(frameNum, layer.Layer)
+
+		objects = append(objects, basgen.IDEntry{Name: name, Color: hex})
+		stmts = append(stmts, basgen.Group{
+			basgen.Let{Name: name, Kind: "path", Props: style.objectProps(pathData, viewBox, ls)},
+			basgen.Chain{
+				{Target: name, Duration: start},
+				{Target: name, Props: basgen.Props{{Key: "alpha", Value: basgen.Num(layer.Alpha * ls.opacity)}}},
+				{Target: name, Duration: end - start},
+				{Target: name, Props: basgen.Props{{Key: "alpha", Value: basgen.Int(0)}}},
+			},
+		})
 	}
 
-	return out.String()
+	return frameResult{stmts: stmts, objects: objects, stats: stats}
 }
@@ -0,0 +1,89 @@
+package json2bas
+
+import (
+	"testing"
+	"video2bas/basgen"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+// layerTimes 解析一帧的 BAS 代码，返回对象显示（alpha 变为非零）与隐藏（alpha 变为 0）的时间
+func layerTimes(t *testing.T, code string) (show, hide int64) {
+	t.Helper()
+	nodes, err := basgen.Parse(code)
+	if err != nil {
+		t.Fatalf("parse: %v\n%s", err, code)
+	}
+	show, hide = -1, -1
+	for _, n := range nodes {
+		chain, ok := n.Stmt.(basgen.Chain)
+		if !ok {
+			continue
+		}
+		at := int64(0)
+		for _, step := range chain {
+			for _, p := range step.Props {
+				if p.Key != "alpha" {
+					continue
+				}
+				if alpha(p.Value) > 0 {
+					show = at
+				} else {
+					hide = at
+				}
+			}
+			at += step.Duration
+		}
+	}
+	if show < 0 || hide < 0 {
+		t.Fatalf("missing show or hide in\n%s", code)
+	}
+	return show, hide
+}
+
+func alpha(v basgen.Value) float64 {
+	switch v := v.(type) {
+	case basgen.Num:
+		return float64(v)
+	case basgen.Int:
+		return float64(v)
+	}
+	return -1
+}
+
+// 生成的每帧显示区间与理想时间轴 i*1000*Den/Num 的误差不超过 0.5ms，累计误差不超过 1ms
+func TestFrameTimingMatchesIdealTimeline(t *testing.T) {
+	for _, fps := range []string{"24", "25", "29.97", "30", "60"} {
+		t.Run(fps, func(t *testing.T) {
+			rate, err := v2btypes.ParseRate(fps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			frames := make([]v2btypes.FrameData, int(10*60*rate.Num/rate.Den))
+			for i := range frames {
+				frames[i] = v2btypes.FrameData{
+					FrameIndex: i,
+					ViewBox:    v2btypes.ViewBox{W: 10, H: 10},
+					Layers:     []v2btypes.Layer{{Color: "FFFFFF", PathData: "M0 0H10V10H0z", Alpha: 1}},
+				}
+			}
+			opts := Options{ViewBox: v2btypes.ViewBox{W: 10, H: 10}, Rate: rate, Path: pathdata.DefaultOptions()}
+			codes, _ := GenerateAllWithOptions(frames, opts, 4)
+			ideal := func(i int) float64 { return float64(i) * 1000 * float64(rate.Den) / float64(rate.Num) }
+			var prevEnd int64
+			for i, code := range codes {
+				start, end := layerTimes(t, code)
+				if d := float64(start) - ideal(i); d > 0.5 || d < -0.5 {
+					t.Fatalf("frame %d shows at %dms, ideal %.3fms", i, start, ideal(i))
+				}
+				if d := float64(end) - ideal(i+1); d > 0.5 || d < -0.5 {
+					t.Fatalf("frame %d hides at %dms, ideal %.3fms", i, end, ideal(i+1))
+				}
+				if i > 0 && start != prevEnd {
+					t.Fatalf("frame %d shows at %dms, previous frame hides at %dms", i, start, prevEnd)
+				}
+				prevEnd = end
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package json2bas
+
+import (
+	"fmt"
+	"video2bas/basgen"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+// poolSlot 表示一个长期存在的对象
+type poolSlot struct {
+	id       string // 输出中的对象名
+	visible  bool
+	style    layerStyle
+	pathData string
+	path     *v2btypes.Path // 当前形状的几何数据，无法解析时为 nil
+	alpha    basgen.Num
+	key      int64 // 当前形状开始显示的时间，补间从这里过渡到下一帧
+	end      int64 // 当前显示区间的结束时间，相对 StartTime
+}
+
+// PoolGenerator 为每个图层槽位（Z 值）声明一次对象，之后每帧只更新其形状、颜色与可见性。
+// Options.Delta 时与上一帧相同且首尾相接的图层只延长显示区间，不再输出；
+// Options.Tween 时与上一帧拓扑相同的图层从上一帧的形状平滑过渡到本帧
+type PoolGenerator struct {
+	opts    Options
+	style   Style
+	path    pathdata.Options
+	viewBox string
+	fresh   bool // 图层变化时隐藏旧对象并声明新对象，而非更新 d；用于 frame 策略的增量模式
+	slots   map[string]*poolSlot
+	order   []string // 按首次出现的顺序，保证输出确定
+	mark    int      // 最近一次 Frame 开始时的标识符分配位置
+}
+
+// NewPoolGenerator 创建对象池生成器
+func NewPoolGenerator(opts Options) *PoolGenerator {
+	g := &PoolGenerator{opts: opts, style: opts.style(), path: opts.Path, viewBox: opts.ViewBox.String()}
+	// 补间要求前后两帧的命令序列一致
+	g.path.Absolute = g.path.Absolute || opts.Tween
+	g.Reset(opts.StartTime)
+	return g
+}
+
+// newDeltaFrameGenerator 创建 frame 策略的增量与补间生成器：按槽位跟踪图层，
+// 未变化的图层延长上一帧对象的显示区间，可补间的图层继续使用上一帧的对象，其余变化时声明新对象
+func newDeltaFrameGenerator(opts Options) *PoolGenerator {
+	g := NewPoolGenerator(opts)
+	g.fresh = true
+	return g
+}
+
+// Reset 丢弃已声明的对象，新分块中会重新声明
+func (g *PoolGenerator) Reset(startTime float64) {
+	if g.opts.IDs != nil && g.slots != nil {
+		g.opts.IDs.Rollback(g.mark)
+	}
+	g.opts.StartTime = startTime
+	g.slots = make(map[string]*poolSlot)
+	g.order = nil
+}
+
+// slotName 返回图层所在槽位的对象名，同一帧内 Z 相同的图层依次占用不同槽位
+func slotName(z, k int) string {
+	if k == 0 {
+		return fmt.Sprintf("s%d", z)
+	}
+	return fmt.Sprintf("s%d_%d", z, k)
+}
+
+// Frame 生成一帧：新槽位声明对象，已有槽位更新属性，本帧消失的槽位在其显示区间结束时隐藏
+func (g *PoolGenerator) Frame(frame v2btypes.FrameData) (string, Stats) {
+	var stmts []basgen.Statement
+	stats := Stats{Skipped: frame.Skipped}
+	opts := g.opts
+
+	shown := make(map[string]bool)
+	seen := make(map[int]int)
+	// 后声明的对象叠在上方，新建对象之后的图层也须重新声明才能保持叠放顺序
+	restack := false
+	if opts.IDs != nil {
+		g.mark = opts.IDs.Mark()
+	}
+
+	for _, layer := range frameLayers(frame) {
+		hex := layer.Color
+		if hex == "000000" && !opts.KeepBlack {
+			continue
+		}
+		if _, err := v2btypes.ParseHexColor(hex); err != nil {
+			stats.Skipped++
+			continue
+		}
+		ls := g.style.resolve(hex)
+		if ls.skip {
+			continue
+		}
+		pathData := layer.raw
+		raw := len(pathData)
+		if pathData == "" {
+			pathData = pathdata.Format(layer.path, g.path)
+			raw = len(layer.path.SVG(opts.Path.Precision))
+		}
+		if pathData == "" {
+			stats.Skipped++
+			continue
+		}
+		name := slotName(layer.Z, seen[layer.Z])
+		seen[layer.Z]++
+		shown[name] = true
+		layerStart, layerEnd := opts.span(frame.FrameIndex, layer.Layer)
+
+		alpha := basgen.Num(layer.Alpha * ls.opacity)
+		slot, ok := g.slots[name]
+		if ok && (opts.Delta || opts.Tween) && !restack && slot.unchanged(pathData, ls, alpha, layerStart) {
+			// 补间时未变化的图层也推迟下一次过渡的起点
+			if opts.Tween {
+				slot.key = layerStart
+			}
+			slot.end = layerEnd
+			stats.Reused++
+			continue
+		}
+		stats.RawPathBytes += raw
+		stats.PathBytes += len(pathData)
+		stats.Layers++
+
+		if ok && opts.Tween && !restack && slot.tweenable(layer, ls, layerStart) {
+			props := basgen.Props{}.Add("d", basgen.Str(pathData))
+			if ls.fill != slot.style.fill {
+				props = props.Add("fillColor", ls.fill)
+			}
+			// 描边随填充色变化时一同过渡
+			props = append(props, ls.changedProps(slot.style)...)
+			if alpha != slot.alpha {
+				props = props.Add("alpha", alpha)
+			}
+			stmts = append(stmts, tweenChain(slot.id, slot.key, layerStart-slot.key, props))
+			stats.Tweened++
+		} else {
+			if ok && g.fresh {
+				// 变化的图层使用新对象，旧对象在其显示区间结束时隐藏
+				if slot.visible {
+					stmts = append(stmts, hideChain(slot.id, slot.end))
+				}
+				ok = false
+			}
+			if !ok {
+				// 首次出现：声明时直接带上形状与颜色，之后只需显示
+				slot = g.declare(name, frame.FrameIndex, hex)
+				restack = g.fresh
+				stmts = append(stmts,
+					basgen.Let{Name: slot.id, Kind: "path", Props: g.style.objectProps(pathData, g.viewBox, ls)},
+					showChain(slot.id, layerStart, basgen.Props{}.Add("alpha", alpha)),
+				)
+			} else {
+				if slot.visible && slot.end < layerStart {
+					stmts = append(stmts, hideChain(slot.id, slot.end))
+				}
+				props := basgen.Props{}.Add("d", basgen.Str(pathData))
+				if ls.fill != slot.style.fill {
+					props = props.Add("fillColor", ls.fill)
+				}
+				props = append(props, ls.changedProps(slot.style)...)
+				stmts = append(stmts, showChain(slot.id, layerStart, props.Add("alpha", alpha)))
+			}
+		}
+		slot.style = ls
+		slot.pathData = pathData
+		slot.path = nil
+		if layer.raw == "" {
+			slot.path = &layer.path
+		}
+		slot.alpha = alpha
+		slot.visible = true
+		slot.key = layerStart
+		slot.end = layerEnd
+	}
+
+	for _, name := range g.order {
+		if slot := g.slots[name]; slot.visible && !shown[name] {
+			stmts = append(stmts, hideChain(slot.id, slot.end))
+			slot.visible = false
+		}
+	}
+	return basgen.Format(stmts...), stats
+}
+
+// declare 为槽位分配对象名。frame 策略的增量模式下每次声明都是新对象，不带标识符时以帧号区分
+func (g *PoolGenerator) declare(name string, frameIndex int, hex string) *poolSlot {
+	slot, ok := g.slots[name]
+	if !ok {
+		slot = &poolSlot{}
+		g.slots[name] = slot
+		g.order = append(g.order, name)
+	}
+	*slot = poolSlot{id: name}
+	if g.fresh {
+		slot.id = fmt.Sprintf("p%d_%s", frameIndex, name)
+	}
+	if g.opts.IDs != nil {
+		slot.id = g.opts.IDs.Next(basgen.IDEntry{Frame: frameIndex, Color: hex, Name: slot.id})
+	}
+	return slot
+}
+
+// unchanged 判断图层与槽位当前显示的内容相同，且紧接在其显示区间之后
+func (s *poolSlot) unchanged(pathData string, ls layerStyle, alpha basgen.Num, start int64) bool {
+	return s.visible && s.end == start && s.pathData == pathData && s.alpha == alpha &&
+		s.style.fill == ls.fill && len(ls.changedProps(s.style)) == 0
+}
+
+// tweenable 判断图层可由槽位当前的形状补间得到：紧接在其显示区间之后、拓扑相同且层级不变
+func (s *poolSlot) tweenable(layer basLayer, ls layerStyle, start int64) bool {
+	return s.visible && s.end == start && s.path != nil && layer.raw == "" &&
+		s.path.SameTopology(layer.path) && sameZIndex(ls.zIndex, s.style.zIndex)
+}
+
+func sameZIndex(a, b *int) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+// Tail 隐藏所有仍可见的对象
+func (g *PoolGenerator) Tail() string {
+	var stmts []basgen.Statement
+	for _, name := range g.order {
+		if slot := g.slots[name]; slot.visible {
+			stmts = append(stmts, hideChain(slot.id, slot.end))
+		}
+	}
+	return basgen.Format(stmts...)
+}
+
+func showChain(name string, at int64, props basgen.Props) basgen.Chain {
+	return basgen.Chain{
+		{Target: name, Duration: at},
+		{Target: name, Props: props},
+	}
+}
+
+// tweenChain 在 from 时刻开始，用 dur 毫秒将 props 过渡到新值
+func tweenChain(name string, from, dur int64, props basgen.Props) basgen.Chain {
+	return basgen.Chain{
+		{Target: name, Duration: from},
+		{Target: name, Props: props, Duration: dur},
+	}
+}
+
+func hideChain(name string, at int64) basgen.Chain {
+	return showChain(name, at, basgen.Props{}.Add("alpha", basgen.Int(0)))
+}
@@ -0,0 +1,327 @@
+package json2bas
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+	"video2bas/basgen"
+	v2btypes "video2bas/type"
+)
+
+// Length 表示位置或尺寸，可为数值或百分比，零值表示不输出
+type Length struct {
+	Value   float64
+	Percent bool
+	Set     bool
+}
+
+// ParseLength 解析 "10%" 或 "120" 形式的长度，空字符串返回零值
+func ParseLength(s string) (Length, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Length{}, nil
+	}
+	l := Length{Set: true}
+	if strings.HasSuffix(s, "%") {
+		l.Percent = true
+		s = strings.TrimSuffix(s, "%")
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return Length{}, fmt.Errorf("invalid length %q", s)
+	}
+	l.Value = v
+	return l, nil
+}
+
+// UnmarshalJSON 接受数值或字符串
+func (l *Length) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		var v float64
+		if err := json.Unmarshal(b, &v); err != nil {
+			return fmt.Errorf("invalid length %s", b)
+		}
+		*l = Length{Value: v, Set: true}
+		return nil
+	}
+	parsed, err := ParseLength(s)
+	*l = parsed
+	return err
+}
+
+// MarshalJSON 输出与 ParseLength 对应的字符串
+func (l Length) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.String())
+}
+
+func (l Length) String() string {
+	if !l.Set {
+		return ""
+	}
+	s := strconv.FormatFloat(l.Value, 'f', -1, 64)
+	if l.Percent {
+		s += "%"
+	}
+	return s
+}
+
+func (l Length) value() basgen.Value {
+	if l.Percent {
+		return basgen.Percent(l.Value)
+	}
+	return basgen.Num(l.Value)
+}
+
+// ColorStyle 为某个调色板颜色覆盖样式，未设置的字段沿用全局样式
+type ColorStyle struct {
+	Skip        bool     `json:"skip,omitempty"`        // 不输出该颜色的图层
+	Fill        string   `json:"fill,omitempty"`        // 替换填充色
+	BorderWidth *float64 `json:"borderWidth,omitempty"` // 描边宽度
+	BorderColor string   `json:"borderColor,omitempty"` // 描边颜色
+	Opacity     *float64 `json:"opacity,omitempty"`     // 与全局不透明度相乘
+	ZIndex      *int     `json:"zIndex,omitempty"`
+}
+
+// Style 控制生成的 path 对象的位置、尺寸与外观
+type Style struct {
+	X           Length   `json:"x"`
+	Y           Length   `json:"y"`
+	Width       Length   `json:"width"`
+	Height      Length   `json:"height"`
+	AnchorX     *float64 `json:"anchorX,omitempty"`
+	AnchorY     *float64 `json:"anchorY,omitempty"`
+	BorderWidth float64  `json:"borderWidth"`           // 0 为无描边
+	BorderColor string   `json:"borderColor,omitempty"` // 为空时与填充色相同
+	Opacity     float64  `json:"opacity"`               // 全局不透明度，与图层 alpha 相乘
+	ZIndex      *int     `json:"zIndex,omitempty"`
+
+	Colors map[string]ColorStyle `json:"colors,omitempty"` // 按 "RRGGBB" 覆盖
+	// ColorTolerance 为 Colors 匹配的 RGB 距离上限：没有完全相同的颜色时使用距离最近的一项。
+	// 视频每帧重新量化调色板，颜色会有细微差别
+	ColorTolerance float64 `json:"colorTolerance"`
+}
+
+// DefaultStyle 返回全屏铺满、描边与填充同色的默认样式
+func DefaultStyle() Style {
+	return Style{
+		Width:          Length{Value: 100, Percent: true, Set: true},
+		BorderWidth:    15,
+		Opacity:        1,
+		ColorTolerance: 48,
+	}
+}
+
+// ParseStyle 以 DefaultStyle 为基础解析 JSON 配置，未出现的字段保持默认值
+func ParseStyle(b []byte) (Style, error) {
+	return ParseStyleWithDefault(b, DefaultStyle())
+}
+
+// ParseStyleWithDefault 与 ParseStyle 相同，以 def 为基础
+func ParseStyleWithDefault(b []byte, def Style) (Style, error) {
+	s := def
+	if err := json.Unmarshal(b, &s); err != nil {
+		return s, err
+	}
+	return s, s.Validate()
+}
+
+// Validate 检查颜色与数值是否合法
+func (s Style) Validate() error {
+	if s.Opacity < 0 || s.Opacity > 1 {
+		return fmt.Errorf("opacity out of range: %v", s.Opacity)
+	}
+	if s.BorderWidth < 0 {
+		return fmt.Errorf("negative borderWidth: %v", s.BorderWidth)
+	}
+	if s.ColorTolerance < 0 {
+		return fmt.Errorf("negative colorTolerance: %v", s.ColorTolerance)
+	}
+	if s.BorderColor != "" {
+		if _, err := v2btypes.ParseHexColor(s.BorderColor); err != nil {
+			return fmt.Errorf("borderColor: %w", err)
+		}
+	}
+	for key, cs := range s.Colors {
+		if _, err := v2btypes.ParseHexColor(key); err != nil {
+			return fmt.Errorf("colors: %w", err)
+		}
+		for _, c := range []string{cs.Fill, cs.BorderColor} {
+			if c == "" {
+				continue
+			}
+			if _, err := v2btypes.ParseHexColor(c); err != nil {
+				return fmt.Errorf("colors.%s: %w", key, err)
+			}
+		}
+		if cs.Opacity != nil && (*cs.Opacity < 0 || *cs.Opacity > 1) {
+			return fmt.Errorf("colors.%s: opacity out of range: %v", key, *cs.Opacity)
+		}
+	}
+	return nil
+}
+
+// layerStyle 为解析后作用于单个图层的样式
+type layerStyle struct {
+	skip        bool
+	fill        basgen.Color
+	borderWidth float64
+	border      basgen.Color
+	opacity     float64
+	zIndex      *int
+}
+
+// resolve 合并全局样式与 hex 颜色的覆盖项；颜色已在 Validate 中检查
+func (s Style) resolve(hex string) layerStyle {
+	fill, _ := v2btypes.ParseHexColor(hex)
+	ls := layerStyle{
+		fill:        basgen.RGB(fill),
+		borderWidth: s.BorderWidth,
+		opacity:     s.Opacity,
+		zIndex:      s.ZIndex,
+	}
+	borderColor := s.BorderColor
+	if cs, ok := s.colorStyle(hex, fill); ok {
+		ls.skip = cs.Skip
+		if cs.Fill != "" {
+			c, _ := v2btypes.ParseHexColor(cs.Fill)
+			ls.fill = basgen.RGB(c)
+		}
+		if cs.BorderWidth != nil {
+			ls.borderWidth = *cs.BorderWidth
+		}
+		if cs.BorderColor != "" {
+			borderColor = cs.BorderColor
+		}
+		if cs.Opacity != nil {
+			ls.opacity *= *cs.Opacity
+		}
+		if cs.ZIndex != nil {
+			ls.zIndex = cs.ZIndex
+		}
+	}
+	ls.border = ls.fill
+	if borderColor != "" {
+		c, _ := v2btypes.ParseHexColor(borderColor)
+		ls.border = basgen.RGB(c)
+	}
+	return ls
+}
+
+// LayerStyle 为某个颜色的图层最终生效的外观，供其他输出格式使用
+type LayerStyle struct {
+	Skip        bool       // 不输出该图层
+	Fill        color.RGBA // 填充色
+	BorderWidth float64    // 描边宽度（viewBox 单位），0 为无描边
+	Border      color.RGBA // 描边颜色
+	Opacity     float64    // 与图层 alpha 相乘的不透明度
+}
+
+// Resolve 返回 hex 颜色的图层在该样式下的外观：合并全局样式与 Colors 中匹配的覆盖项
+func (s Style) Resolve(hex string) LayerStyle {
+	ls := s.resolve(hex)
+	rgba := func(c basgen.Color) color.RGBA {
+		return color.RGBA{R: uint8(c >> 16), G: uint8(c >> 8), B: uint8(c), A: 255}
+	}
+	return LayerStyle{Skip: ls.skip, Fill: rgba(ls.fill), BorderWidth: ls.borderWidth, Border: rgba(ls.border), Opacity: ls.opacity}
+}
+
+// colorStyle 返回 hex 颜色的覆盖项：优先完全相同的颜色，否则取 ColorTolerance 内距离最近的一项
+func (s Style) colorStyle(hex string, c color.RGBA) (ColorStyle, bool) {
+	if cs, ok := s.Colors[strings.ToUpper(strings.TrimPrefix(hex, "#"))]; ok {
+		return cs, true
+	}
+	best, bestKey, bestDist := ColorStyle{}, "", math.Inf(1)
+	for key, cs := range s.Colors {
+		k, err := v2btypes.ParseHexColor(key)
+		if err != nil {
+			continue
+		}
+		dr, dg, db := float64(k.R)-float64(c.R), float64(k.G)-float64(c.G), float64(k.B)-float64(c.B)
+		d := math.Sqrt(dr*dr + dg*dg + db*db)
+		// 距离相同时按键名选取，保证结果确定
+		if d <= s.ColorTolerance && (d < bestDist || d == bestDist && key < bestKey) {
+			best, bestKey, bestDist = cs, key, d
+		}
+	}
+	return best, bestKey != ""
+}
+
+// objectProps 返回声明 path 对象时的全部属性，对象初始隐藏
+func (s Style) objectProps(d, viewBox string, ls layerStyle) basgen.Props {
+	props := basgen.Props{}.
+		Add("d", basgen.Str(d)).
+		Add("viewBox", basgen.Str(viewBox))
+	for _, l := range []struct {
+		key string
+		len Length
+	}{{"x", s.X}, {"y", s.Y}, {"width", s.Width}, {"height", s.Height}} {
+		if l.len.Set {
+			props = props.Add(l.key, l.len.value())
+		}
+	}
+	if s.AnchorX != nil {
+		props = props.Add("anchorX", basgen.Num(*s.AnchorX))
+	}
+	if s.AnchorY != nil {
+		props = props.Add("anchorY", basgen.Num(*s.AnchorY))
+	}
+	props = props.Add("fillColor", ls.fill).Add("alpha", basgen.Int(0))
+	return append(props, ls.changedProps(layerStyle{borderWidth: -1, border: ^basgen.Color(0)})...)
+}
+
+// changedProps 返回相对 prev 发生变化的描边与层级属性
+func (ls layerStyle) changedProps(prev layerStyle) basgen.Props {
+	var props basgen.Props
+	if ls.borderWidth != prev.borderWidth {
+		props = props.Add("borderWidth", basgen.Num(ls.borderWidth))
+	}
+	if ls.borderWidth > 0 && (ls.border != prev.border || prev.borderWidth <= 0) {
+		props = props.Add("borderColor", ls.border)
+	}
+	if ls.zIndex != nil && (prev.zIndex == nil || *ls.zIndex != *prev.zIndex) {
+		props = props.Add("zIndex", basgen.Int(*ls.zIndex))
+	}
+	return props
+}
+
+// style 返回生效的样式，未设置时使用 DefaultStyle。
+// 样式未设置高度时按 Fit 放置对象：stretch 宽高均铺满播放器；letterbox 与 fill 在样式也未改动宽度与位置时，
+// 按 viewBox 与播放器的宽高比铺满宽度或高度，另一方向居中
+func (opts Options) style() Style {
+	s := DefaultStyle()
+	if opts.Style != nil {
+		s = *opts.Style
+	}
+	if s.Height.Set {
+		return s
+	}
+	full := Length{Value: 100, Percent: true, Set: true}
+	if opts.Fit == v2btypes.FitStretch {
+		s.Height = full
+		return s
+	}
+	if s.Width != full || s.X.Set || s.Y.Set || s.AnchorX != nil || s.AnchorY != nil || !(opts.ViewBox.W > 0 && opts.ViewBox.H > 0) {
+		return s
+	}
+	player := opts.Player
+	if !(player > 0) {
+		player = 16.0 / 9
+	}
+	aspect := opts.ViewBox.W / opts.ViewBox.H
+	if math.Abs(aspect-player) < 1e-3*player {
+		return s
+	}
+	center, half := Length{Value: 50, Percent: true, Set: true}, 0.5
+	// letterbox 时比播放器更宽的画面铺满宽度，fill 时铺满高度
+	if (aspect > player) != (opts.Fit == v2btypes.FitFill) {
+		s.Y, s.AnchorY = center, &half
+	} else {
+		s.Width, s.Height = Length{}, full
+		s.X, s.AnchorX = center, &half
+	}
+	return s
+}
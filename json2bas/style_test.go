@@ -0,0 +1,39 @@
+package json2bas
+
+import (
+	"testing"
+	"video2bas/basgen"
+	v2btypes "video2bas/type"
+)
+
+// 未设置尺寸与位置时按 Fit 与播放器宽高比放置对象
+func TestFitPlacement(t *testing.T) {
+	for _, tc := range []struct {
+		fit     v2btypes.Fit
+		viewBox v2btypes.ViewBox
+		want    string
+	}{
+		{v2btypes.FitLetterbox, v2btypes.ViewBox{W: 1920, H: 1080}, "width=100%"},
+		{v2btypes.FitFill, v2btypes.ViewBox{W: 1920, H: 1080}, "width=100%"},
+		{v2btypes.FitStretch, v2btypes.ViewBox{W: 1920, H: 1080}, "width=100% height=100%"},
+		{v2btypes.FitLetterbox, v2btypes.ViewBox{W: 400, H: 300}, "x=50% height=100% anchorX=0.5"},
+		{v2btypes.FitFill, v2btypes.ViewBox{W: 400, H: 300}, "y=50% width=100% anchorY=0.5"},
+		{v2btypes.FitLetterbox, v2btypes.ViewBox{W: 2400, H: 1000}, "y=50% width=100% anchorY=0.5"},
+		{v2btypes.FitFill, v2btypes.ViewBox{W: 2400, H: 1000}, "x=50% height=100% anchorX=0.5"},
+		{v2btypes.FitStretch, v2btypes.ViewBox{W: 400, H: 300}, "width=100% height=100%"},
+	} {
+		opts := Options{Fit: tc.fit, ViewBox: tc.viewBox}
+		props := opts.style().objectProps("M0 0", tc.viewBox.String(), layerStyle{})
+		var placement basgen.Props
+		for _, p := range props {
+			switch p.Key {
+			case "x", "y", "width", "height", "anchorX", "anchorY":
+				placement = append(placement, p)
+			}
+		}
+		got := basgen.Format(basgen.Let{Name: "a", Kind: "path", Props: placement})
+		if want := "let a = path{" + tc.want + "}\n"; got != want {
+			t.Errorf("%s %gx%g: got %q, want %q", tc.fit, tc.viewBox.W, tc.viewBox.H, got, want)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package json2lottie
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+// Options 控制 Lottie 输出
+type Options struct {
+	Rate      v2btypes.Rate
+	Precision int  // 坐标保留的小数位数
+	KeepBlack bool // 保留黑色图层；视频转换时黑色视为背景跳过
+	Keyframes bool // 相邻帧同一图层拓扑一致时合并为一个带路径关键帧的图层
+	Title     string
+}
+
+// DefaultOptions 返回 10fps、合并关键帧的设置
+func DefaultOptions() Options {
+	return Options{Rate: v2btypes.Rate{Num: 10, Den: 1}, Keyframes: true}
+}
+
+// shape 为 Lottie 的贝塞尔路径，i、o 为相对顶点的入、出控制柄
+type shape struct {
+	I [][2]float64 `json:"i"`
+	O [][2]float64 `json:"o"`
+	V [][2]float64 `json:"v"`
+	C bool         `json:"c"`
+}
+
+// track 为输出的一个形状图层：同一位置、同一颜色、拓扑一致的连续若干帧
+type track struct {
+	z        int // 所在图层的 Z
+	color    color.RGBA
+	alpha    float64
+	ip, op   float64   // 以 Lottie 帧为单位
+	times    []float64 // 各关键帧时间
+	shapes   [][]shape // 各关键帧的子路径
+	topology []int     // 各子路径的顶点数，闭合时取负
+}
+
+// slotKey 标识一帧中图层的位置，用于在相邻帧间匹配
+type slotKey struct {
+	z     int
+	color string
+	n     int // 同一 Z 与颜色的第几个图层
+}
+
+// Writer 收集全部帧，在 Close 时写出 Lottie（bodymovin）JSON
+type Writer struct {
+	w       io.Writer
+	opts    Options
+	viewBox v2btypes.ViewBox
+	frames  int
+	tracks  []*track
+	open    map[slotKey]*track // 可由下一帧延续的图层
+}
+
+// NewWriter 创建写入 w 的 Lottie 编码器
+func NewWriter(w io.Writer, opts Options) *Writer {
+	return &Writer{w: w, opts: opts, open: make(map[slotKey]*track)}
+}
+
+// WriteFrame 加入一帧，viewBox 以第一帧为准
+func (w *Writer) WriteFrame(fd v2btypes.FrameData) error {
+	if w.frames == 0 {
+		w.viewBox = fd.ViewBox
+	}
+	w.frames++
+	layers := append([]v2btypes.Layer(nil), fd.Layers...)
+	sort.SliceStable(layers, func(i, j int) bool { return layers[i].Z < layers[j].Z })
+
+	open := make(map[slotKey]*track)
+	counts := make(map[slotKey]int)
+	for _, l := range layers {
+		if l.Color == "000000" && !w.opts.KeepBlack {
+			continue
+		}
+		c, err := v2btypes.ParseHexColor(l.Color)
+		if err != nil {
+			return fmt.Errorf("frame %d: %w", fd.FrameIndex, err)
+		}
+		path, err := layerPath(l)
+		if err != nil {
+			return fmt.Errorf("frame %d: %w", fd.FrameIndex, err)
+		}
+		shapes := w.shapes(path)
+		if len(shapes) == 0 {
+			continue
+		}
+		ip, op := w.span(fd.FrameIndex, l)
+
+		base := slotKey{z: l.Z, color: l.Color}
+		key := base
+		key.n = counts[base]
+		counts[base]++
+
+		topo := topology(shapes)
+		t := w.open[key]
+		if w.opts.Keyframes && t != nil && t.alpha == l.Alpha && math.Abs(t.op-ip) < 1e-6 && equalInts(t.topology, topo) {
+			t.op = op
+			// 图形未变化时只延长显示时间
+			if !reflect.DeepEqual(t.shapes[len(t.shapes)-1], shapes) {
+				t.times = append(t.times, ip)
+				t.shapes = append(t.shapes, shapes)
+			}
+		} else {
+			t = &track{z: l.Z, color: c, alpha: l.Alpha, ip: ip, op: op, times: []float64{ip}, shapes: [][]shape{shapes}, topology: topo}
+			w.tracks = append(w.tracks, t)
+		}
+		open[key] = t
+	}
+	w.open = open
+	return nil
+}
+
+// span 返回图层的显示区间，单位为 Lottie 帧
+func (w *Writer) span(frameIndex int, l v2btypes.Layer) (float64, float64) {
+	if !l.HasTiming() {
+		return float64(frameIndex), float64(frameIndex + 1)
+	}
+	k := float64(w.opts.Rate.Num) / float64(1000*w.opts.Rate.Den)
+	return float64(l.Start) * k, float64(l.End) * k
+}
+
+// Layers 返回将输出的形状图层数
+func (w *Writer) Layers() int {
+	return len(w.tracks)
+}
+
+// Close 写出完整的 Lottie JSON
+func (w *Writer) Close() error {
+	title := w.opts.Title
+	if title == "" {
+		title = "video2bas"
+	}
+	var op float64
+	for _, t := range w.tracks {
+		op = math.Max(op, t.op)
+	}
+	// 按 Z 由下到上排列，Z 相同时后创建的在上方；Lottie 中靠前的图层在上方，需要倒序
+	tracks := append([]*track(nil), w.tracks...)
+	sort.SliceStable(tracks, func(i, j int) bool { return tracks[i].z < tracks[j].z })
+	layers := make([]any, 0, len(tracks))
+	for i := len(tracks) - 1; i >= 0; i-- {
+		layers = append(layers, tracks[i].layer(len(layers)+1))
+	}
+	doc := map[string]any{
+		"v":      "5.7.4",
+		"fr":     w.opts.Rate.Float(),
+		"ip":     0,
+		"op":     op,
+		"w":      math.Round(w.viewBox.W),
+		"h":      math.Round(w.viewBox.H),
+		"nm":     title,
+		"ddd":    0,
+		"assets": []any{},
+		"layers": layers,
+	}
+	return json.NewEncoder(w.w).Encode(doc)
+}
+
+func static(v any) map[string]any {
+	return map[string]any{"a": 0, "k": v}
+}
+
+// layer 输出形状图层：每个子路径为一个 sh，多个关键帧时使用定格关键帧逐帧切换
+func (t *track) layer(index int) map[string]any {
+	items := make([]any, 0, len(t.topology)+2)
+	for j := range t.topology {
+		var ks map[string]any
+		if len(t.times) == 1 {
+			ks = static(t.shapes[0][j])
+		} else {
+			keys := make([]any, len(t.times))
+			for k, tm := range t.times {
+				keys[k] = map[string]any{"t": tm, "s": []shape{t.shapes[k][j]}, "h": 1}
+			}
+			ks = map[string]any{"a": 1, "k": keys}
+		}
+		items = append(items, map[string]any{"ty": "sh", "nm": fmt.Sprintf("Path %d", j+1), "ks": ks})
+	}
+	c := t.color
+	items = append(items,
+		map[string]any{"ty": "fl", "nm": "Fill", "r": 1, "o": static(100),
+			"c": static([]float64{float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255, 1})},
+		map[string]any{"ty": "tr", "p": static([]float64{0, 0}), "a": static([]float64{0, 0}),
+			"s": static([]float64{100, 100}), "r": static(0), "o": static(100)},
+	)
+	return map[string]any{
+		"ddd": 0,
+		"ind": index,
+		"ty":  4,
+		"nm":  fmt.Sprintf("%02X%02X%02X %g", c.R, c.G, c.B, t.ip),
+		"sr":  1,
+		"ks": map[string]any{
+			"o": static(math.Round(math.Max(0, math.Min(1, t.alpha)) * 100)),
+			"r": static(0),
+			"p": static([]float64{0, 0, 0}),
+			"a": static([]float64{0, 0, 0}),
+			"s": static([]float64{100, 100, 100}),
+		},
+		"ao":     0,
+		"shapes": []any{map[string]any{"ty": "gr", "nm": "Layer", "it": items}},
+		"ip":     t.ip,
+		"op":     t.op,
+		"st":     0,
+		"bm":     0,
+	}
+}
+
+func layerPath(l v2btypes.Layer) (v2btypes.Path, error) {
+	if l.Path != nil {
+		return *l.Path, nil
+	}
+	return pathdata.Parse(l.PathData)
+}
+
+// shapes 将路径转换为 Lottie 子路径，坐标平移到以 viewBox 左上角为原点
+func (w *Writer) shapes(p v2btypes.Path) []shape {
+	scale := math.Pow(10, float64(w.opts.Precision))
+	pt := func(p v2btypes.Point) [2]float64 {
+		return [2]float64{math.Round((p.X-w.viewBox.X)*scale) / scale, math.Round((p.Y-w.viewBox.Y)*scale) / scale}
+	}
+	sub := func(a, b [2]float64) [2]float64 {
+		return [2]float64{math.Round((a[0]-b[0])*scale) / scale, math.Round((a[1]-b[1])*scale) / scale}
+	}
+	var out []shape
+	for _, sp := range p.SubPaths {
+		if len(sp.Segments) == 0 {
+			continue
+		}
+		s := shape{C: sp.Closed, V: [][2]float64{pt(sp.Start)}, I: [][2]float64{{0, 0}}, O: [][2]float64{{0, 0}}}
+		for _, seg := range sp.Segments {
+			last := len(s.V) - 1
+			end := pt(seg.End())
+			if seg.Kind == v2btypes.SegCubic {
+				s.O[last] = sub(pt(seg.Pts[0]), s.V[last])
+				s.V = append(s.V, end)
+				s.I = append(s.I, sub(pt(seg.Pts[1]), end))
+			} else {
+				s.V = append(s.V, end)
+				s.I = append(s.I, [2]float64{0, 0})
+			}
+			s.O = append(s.O, [2]float64{0, 0})
+		}
+		// 闭合路径回到起点的最后一段由 c 表示，去掉重复的终点并将其入控制柄交给起点
+		if n := len(s.V) - 1; sp.Closed && n > 0 && s.V[n] == s.V[0] {
+			s.I[0] = s.I[n]
+			s.V, s.I, s.O = s.V[:n], s.I[:n], s.O[:n]
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// topology 返回各子路径的顶点数，闭合路径取负，用于判断能否作为关键帧插值
+func topology(shapes []shape) []int {
+	t := make([]int, len(shapes))
+	for i, s := range shapes {
+		t[i] = len(s.V)
+		if s.C {
+			t[i] = -t[i]
+		}
+	}
+	return t
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package json2lottie
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	v2btypes "video2bas/type"
+)
+
+// 图层按 Z 从上到下输出，不受创建先后影响：第二帧新出现的底层图层仍在上一帧延续下来的顶层图层之下
+func TestLayersOrderedByZ(t *testing.T) {
+	frame := func(i int, bottom string) v2btypes.FrameData {
+		return v2btypes.FrameData{
+			FrameIndex: i,
+			ViewBox:    v2btypes.ViewBox{W: 10, H: 10},
+			Layers: []v2btypes.Layer{
+				{Z: 0, Color: bottom, PathData: "M0 0H10V10H0z", Alpha: 1},
+				{Z: 1, Color: "FF0000", PathData: "M2 2H8V8H2z", Alpha: 1},
+			},
+		}
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf, DefaultOptions())
+	for i, bottom := range []string{"FFFFFF", "EEEEEE"} {
+		if err := w.WriteFrame(frame(i, bottom)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Layers []struct {
+			Ind int    `json:"ind"`
+			Nm  string `json:"nm"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for i, l := range doc.Layers {
+		if l.Ind != i+1 {
+			t.Errorf("layer %d has ind %d", i, l.Ind)
+		}
+		got = append(got, l.Nm)
+	}
+	want := []string{"FF0000 0", "EEEEEE 1", "FFFFFF 0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("layers top to bottom = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package json2svg
+
+import (
+	"fmt"
+	"html"
+	"io"
+	v2btypes "video2bas/type"
+)
+
+// page 为 HTML 预览页的参数：内嵌 SVG 覆盖在源视频上，并以滑块控制时间
+type page struct {
+	video string // 视频地址，为空时只显示黑色背景
+}
+
+// NewHTMLWriter 创建输出 HTML 预览页的 Writer。video 为页面中引用的视频地址，可为空
+func NewHTMLWriter(w io.Writer, opts Options, video string) *Writer {
+	x := NewWriter(w, opts)
+	x.page = &page{video: video}
+	return x
+}
+
+func (p *page) writeHeader(w *Writer, vb v2btypes.ViewBox) {
+	aspect := "16 / 9"
+	if vb.W > 0 && vb.H > 0 {
+		aspect = num(vb.W) + " / " + num(vb.H)
+	}
+	fmt.Fprintf(w.w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { margin: 0; padding: 16px; background: #222; color: #eee; font: 14px sans-serif; }
+#stage { position: relative; max-width: 960px; aspect-ratio: %s; background: #000; }
+#stage video, #stage svg { position: absolute; inset: 0; width: 100%%; height: 100%%; }
+#controls { display: flex; gap: 8px; align-items: center; max-width: 960px; margin-top: 8px; }
+#time { flex: 1; }
+</style>
+</head>
+<body>
+<div id="stage">
+`, html.EscapeString(w.title()), aspect)
+	if p.video != "" {
+		// 视频按与帧坐标相同的方式适配画面，保证两者对齐
+		fit := ""
+		switch w.opts.Fit {
+		case v2btypes.FitFill:
+			fit = ` style="object-fit: cover"`
+		case v2btypes.FitStretch:
+			fit = ` style="object-fit: fill"`
+		}
+		fmt.Fprintf(w.w, "<video src=\"%s\" preload=\"auto\"%s></video>\n", html.EscapeString(p.video), fit)
+	}
+}
+
+func (p *page) writeFooter(w *Writer) {
+	fmt.Fprintf(w.w, `</div>
+<div id="controls">
+<button id="play">Play</button>
+<input id="time" type="range" min="0" max="%d" step="1" value="0">
+<span id="label">0.00s</span>
+</div>
+<script>
+const svg = document.querySelector("#stage svg");
+const video = document.querySelector("#stage video");
+const slider = document.getElementById("time");
+const label = document.getElementById("label");
+const button = document.getElementById("play");
+const duration = %d;
+let playing = false, origin = 0, offset = 0;
+svg.pauseAnimations();
+
+// 有视频时以视频进度为准，否则使用页面自身的时钟
+function now() {
+  if (video) return video.currentTime * 1000;
+  return playing ? Math.min(duration, offset + performance.now() - origin) : offset;
+}
+function seek(ms) {
+  if (video) video.currentTime = ms / 1000;
+  offset = ms;
+  origin = performance.now();
+}
+function setPlaying(on) {
+  if (on && now() >= duration) seek(0);
+  offset = now();
+  origin = performance.now();
+  playing = on;
+  if (video) on ? video.play() : video.pause();
+  button.textContent = on ? "Pause" : "Play";
+}
+button.onclick = () => setPlaying(!playing);
+slider.oninput = () => seek(Number(slider.value));
+if (video) video.onended = () => setPlaying(false);
+(function tick() {
+  const ms = now();
+  if (playing && !video && ms >= duration) setPlaying(false);
+  svg.setCurrentTime(ms / 1000);
+  slider.value = ms;
+  label.textContent = (ms / 1000).toFixed(2) + "s";
+  requestAnimationFrame(tick);
+})();
+</script>
+</body>
+</html>
+`, w.end, w.end)
+}
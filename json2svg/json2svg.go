@@ -0,0 +1,167 @@
+package json2svg
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+// Options 控制 SVG 输出
+type Options struct {
+	Rate      v2btypes.Rate
+	Path      pathdata.Options // 与 json2bas 相同的路径格式化参数，保证图形一致
+	KeepBlack bool             // 保留黑色图层；视频转换时黑色视为背景跳过
+	Fit       v2btypes.Fit     // 显示区域与 viewBox 比例不一致时的适配方式，为空时等比居中
+	Title     string
+}
+
+// DefaultOptions 返回 10fps、坐标取整的设置
+func DefaultOptions() Options {
+	return Options{Rate: v2btypes.Rate{Num: 10, Den: 1}}
+}
+
+// Writer 逐帧写出 SMIL 动画 SVG，每帧为一个 <g>，由 <set> 在帧的显示区间内设为可见
+type Writer struct {
+	w      *bufio.Writer
+	opts   Options
+	header bool
+	frames int
+	end    int64 // 最后一个图层结束的时间（毫秒）
+	page   *page // 非空时输出为 HTML 预览页
+}
+
+// NewWriter 创建写入 w 的 SVG 编码器
+func NewWriter(w io.Writer, opts Options) *Writer {
+	return &Writer{w: bufio.NewWriter(w), opts: opts}
+}
+
+func (w *Writer) title() string {
+	if w.opts.Title == "" {
+		return "video2bas"
+	}
+	return w.opts.Title
+}
+
+// writeHeader 以第一帧的 viewBox 写出 <svg> 开始标签
+func (w *Writer) writeHeader(vb v2btypes.ViewBox) {
+	if w.page != nil {
+		w.page.writeHeader(w, vb)
+	} else {
+		fmt.Fprintf(w.w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	}
+	fmt.Fprintf(w.w, `<svg xmlns="http://www.w3.org/2000/svg"`)
+	if vb.W > 0 && vb.H > 0 {
+		fmt.Fprintf(w.w, ` viewBox="%s %s %s %s"`, num(vb.X), num(vb.Y), num(vb.W), num(vb.H))
+		if w.opts.Fit != "" && w.opts.Fit != v2btypes.FitLetterbox {
+			fmt.Fprintf(w.w, ` preserveAspectRatio="%s"`, w.opts.Fit.PreserveAspectRatio())
+		}
+	}
+	fmt.Fprintf(w.w, ">\n<title>%s</title>\n", html.EscapeString(w.title()))
+	w.header = true
+}
+
+// WriteFrame 写出一帧，图层按 Z 从下到上排列。与帧区间不同的图层单独设置可见时间
+func (w *Writer) WriteFrame(fd v2btypes.FrameData) error {
+	if !w.header {
+		w.writeHeader(fd.ViewBox)
+	}
+	layers := append([]v2btypes.Layer(nil), fd.Layers...)
+	sort.SliceStable(layers, func(i, j int) bool { return layers[i].Z < layers[j].Z })
+
+	start, end := w.frameSpan(fd)
+	fmt.Fprintf(w.w, "<g id=\"f%d\" visibility=\"hidden\">%s\n", fd.FrameIndex, visible(start, end-start))
+	for _, l := range layers {
+		if l.Color == "000000" && !w.opts.KeepBlack {
+			continue
+		}
+		if _, err := v2btypes.ParseHexColor(l.Color); err != nil {
+			return fmt.Errorf("frame %d: %w", fd.FrameIndex, err)
+		}
+		path, err := layerPath(l)
+		if err != nil {
+			return fmt.Errorf("frame %d: %w", fd.FrameIndex, err)
+		}
+		d := pathdata.Format(path, w.opts.Path)
+		if d == "" {
+			continue
+		}
+		fmt.Fprintf(w.w, `<path d="%s" fill="#%s"`, d, l.Color)
+		if l.Alpha < 1 {
+			fmt.Fprintf(w.w, ` fill-opacity="%s"`, num(math.Max(0, l.Alpha)))
+		}
+		ls, le := start, end
+		if l.HasTiming() && (l.Start != start || l.End != end) {
+			ls, le = l.Start, l.End
+			fmt.Fprintf(w.w, ` visibility="hidden">%s</path>`+"\n", visible(ls, le-ls))
+		} else {
+			fmt.Fprintf(w.w, "/>\n")
+		}
+		w.end = max(w.end, le)
+	}
+	fmt.Fprintf(w.w, "</g>\n")
+	w.end = max(w.end, end)
+	w.frames++
+	return nil
+}
+
+// frameSpan 返回帧的显示区间：所有图层带有相同时间时以其为准，否则按帧率计算
+func (w *Writer) frameSpan(fd v2btypes.FrameData) (int64, int64) {
+	start, end := w.opts.Rate.FrameStart(fd.FrameIndex), w.opts.Rate.FrameEnd(fd.FrameIndex)
+	for i, l := range fd.Layers {
+		if !l.HasTiming() || (i > 0 && (l.Start != fd.Layers[0].Start || l.End != fd.Layers[0].End)) {
+			return start, end
+		}
+	}
+	if len(fd.Layers) > 0 {
+		return fd.Layers[0].Start, fd.Layers[0].End
+	}
+	return start, end
+}
+
+// Close 写出结尾并刷新缓冲区
+func (w *Writer) Close() error {
+	if !w.header {
+		w.writeHeader(v2btypes.ViewBox{})
+	}
+	fmt.Fprintf(w.w, "</svg>\n")
+	if w.page != nil {
+		w.page.writeFooter(w)
+	}
+	return w.w.Flush()
+}
+
+// Frames 返回已写出的帧数
+func (w *Writer) Frames() int {
+	return w.frames
+}
+
+// Duration 返回动画总时长（毫秒）
+func (w *Writer) Duration() int64 {
+	return w.end
+}
+
+func layerPath(l v2btypes.Layer) (v2btypes.Path, error) {
+	if l.Path != nil {
+		return *l.Path, nil
+	}
+	return pathdata.Parse(l.PathData)
+}
+
+// visible 返回在 begin 毫秒时可见、持续 dur 毫秒的 <set>。SVG 只有一条文档时间轴，
+// 嵌套元素的 begin 同样从文档开始计算
+func visible(begin, dur int64) string {
+	if dur < 0 {
+		dur = 0
+	}
+	return fmt.Sprintf(`<set attributeName="visibility" to="visible" begin="%dms" dur="%dms"/>`, begin, dur)
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
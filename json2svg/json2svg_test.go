@@ -0,0 +1,129 @@
+package json2svg
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	v2btypes "video2bas/type"
+)
+
+func render(t *testing.T, w *Writer, sb *strings.Builder, frames ...v2btypes.FrameData) string {
+	t.Helper()
+	for _, fd := range frames {
+		if err := w.WriteFrame(fd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+var testFrames = []v2btypes.FrameData{
+	{FrameIndex: 0, ViewBox: v2btypes.ViewBox{W: 160, H: 90}, Layers: []v2btypes.Layer{
+		{Color: "000000", Z: 0, Alpha: 1, PathData: "M0 0H160V90H0z"},
+		{Color: "FFFFFF", Z: 2, Alpha: 0.5, PathData: "M0 0H10V10H0z"},
+		{Color: "FF0000", Z: 1, Alpha: 1, PathData: "M5 5H15V15H5z"},
+	}},
+	{FrameIndex: 1, ViewBox: v2btypes.ViewBox{W: 160, H: 90}, Layers: []v2btypes.Layer{
+		{Color: "00FF00", Alpha: 1, PathData: "M0 0H1V1H0z", Start: 100, End: 400},
+	}},
+	{FrameIndex: 2, ViewBox: v2btypes.ViewBox{W: 160, H: 90}, Layers: []v2btypes.Layer{
+		{Color: "0000FF", Alpha: 1, PathData: "M0 0H1V1H0z", Start: 200, End: 300},
+		{Color: "FFFF00", Alpha: 1, PathData: "M0 0H1V1H0z", Start: 200, End: 900},
+	}},
+}
+
+// 每帧一个 <g>，按帧区间可见；图层按 Z 排序，黑色背景跳过；
+// 全部图层时间相同时以其为帧区间，否则按帧率计算并为不同的图层单独设置时间
+func TestWriteFrame(t *testing.T) {
+	var sb strings.Builder
+	w := NewWriter(&sb, DefaultOptions())
+	out := render(t, w, &sb, testFrames...)
+
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 160 90">`,
+		`<g id="f0" visibility="hidden"><set attributeName="visibility" to="visible" begin="0ms" dur="100ms"/>` + "\n" +
+			`<path d="M5 5H15V15H5z" fill="#FF0000"/>` + "\n" +
+			`<path d="M0 0H10V10H0z" fill="#FFFFFF" fill-opacity="0.5"/>` + "\n</g>",
+		`<g id="f1" visibility="hidden"><set attributeName="visibility" to="visible" begin="100ms" dur="300ms"/>` + "\n" +
+			`<path d="M0 0H1V1H0z" fill="#00FF00"/>`,
+		`<g id="f2" visibility="hidden"><set attributeName="visibility" to="visible" begin="200ms" dur="100ms"/>` + "\n" +
+			`<path d="M0 0H1V1H0z" fill="#0000FF"/>` + "\n" +
+			`<path d="M0 0H1V1H0z" fill="#FFFF00" visibility="hidden"><set attributeName="visibility" to="visible" begin="200ms" dur="700ms"/></path>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing\n%s\ngot\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "#000000") {
+		t.Errorf("black background layer written:\n%s", out)
+	}
+	if w.Frames() != 3 || w.Duration() != 900 {
+		t.Errorf("frames %d duration %d, want 3 and 900", w.Frames(), w.Duration())
+	}
+	checkXML(t, out)
+}
+
+// 非 letterbox 的适配方式写出 preserveAspectRatio；无效颜色返回错误
+func TestWriterOptions(t *testing.T) {
+	var sb strings.Builder
+	opts := DefaultOptions()
+	opts.Fit = v2btypes.FitStretch
+	opts.Title = "a<b"
+	out := render(t, NewWriter(&sb, opts), &sb, testFrames[0])
+	if !strings.Contains(out, `preserveAspectRatio="none"`) || !strings.Contains(out, "<title>a&lt;b</title>") {
+		t.Errorf("got\n%s", out)
+	}
+
+	err := NewWriter(io.Discard, opts).WriteFrame(v2btypes.FrameData{FrameIndex: 7, Layers: []v2btypes.Layer{{Color: "red", PathData: "M0 0H1V1z"}}})
+	if err == nil || !strings.HasPrefix(err.Error(), "frame 7:") {
+		t.Errorf("got %v, want an invalid color error for frame 7", err)
+	}
+}
+
+// 预览页内嵌 SVG，视频按 Fit 对齐，滑块范围为动画时长
+func TestHTMLWriter(t *testing.T) {
+	var sb strings.Builder
+	opts := DefaultOptions()
+	opts.Fit = v2btypes.FitFill
+	out := render(t, NewHTMLWriter(&sb, opts, "a&b.mp4"), &sb, testFrames...)
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"aspect-ratio: 160 / 90;",
+		`<video src="a&amp;b.mp4" preload="auto" style="object-fit: cover"></video>`,
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 160 90" preserveAspectRatio="xMidYMid slice">`,
+		`max="900"`,
+		"const duration = 900;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+	if strings.Contains(out, "<?xml") {
+		t.Error("page contains an XML declaration")
+	}
+
+	// 没有视频时不输出 <video>
+	sb.Reset()
+	out = render(t, NewHTMLWriter(&sb, DefaultOptions(), ""), &sb, testFrames[0])
+	if strings.Contains(out, "<video") {
+		t.Error("page without video contains <video>")
+	}
+}
+
+func checkXML(t *testing.T, s string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(s))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("invalid XML: %v", err)
+		}
+	}
+}
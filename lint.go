@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"video2bas/basgen"
+)
+
+// runLint 处理 lint 子命令：检查生成的 .bas.txt 分块，发现问题时以非零状态退出
+func runLint(args []string) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	maxFileSize := fs.Int("maxsize", 2*1024*1024, "单个文件最大尺寸，单位字节，0 为不检查")
+	maxLine := fs.Int("maxline", 64*1024, "单行最大字节数，0 为不检查")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: video2bas lint [flags] <file | dir | output prefix>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var names []string
+	for _, arg := range fs.Args() {
+		found, err := chunkFiles(arg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		names = append(names, found...)
+	}
+	files := make([]basgen.LintFile, len(names))
+	for i, name := range names {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		files[i] = basgen.LintFile{Name: name, Data: string(b)}
+	}
+
+	diags := basgen.Lint(files, basgen.LintOptions{MaxLine: *maxLine, MaxSize: *maxFileSize})
+	for _, d := range diags {
+		fmt.Println(d)
+	}
+	if len(diags) > 0 {
+		log.Printf("%d problems in %d files", len(diags), len(files))
+		os.Exit(1)
+	}
+	log.Printf("%d files OK", len(files))
+}
+
+var chunkNumberRe = regexp.MustCompile(`_(\d+)\.bas\.txt$`)
+
+// chunkFiles 展开参数：文件原样返回，目录取其中全部 .bas.txt，其余视为 -output 前缀；
+// 结果按分块编号排序
+func chunkFiles(arg string) ([]string, error) {
+	var files []string
+	if info, err := os.Stat(arg); err == nil {
+		if !info.IsDir() {
+			return []string{arg}, nil
+		}
+		if files, err = filepath.Glob(filepath.Join(arg, "*.bas.txt")); err != nil {
+			return nil, err
+		}
+	} else {
+		if files, err = filepath.Glob(arg + "_*.bas.txt"); err != nil {
+			return nil, err
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s: no .bas.txt files found", arg)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := chunkNumber(files[i]), chunkNumber(files[j])
+		if a != b {
+			return a < b
+		}
+		return files[i] < files[j]
+	})
+	return files, nil
+}
+
+func chunkNumber(name string) int {
+	if m := chunkNumberRe.FindStringSubmatch(name); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return -1
+}
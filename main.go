@@ -3,18 +3,53 @@ package main
 import (
 	"context"
 	"flag"
+	"log"
+	"os"
+	"video2bas/color2svg"
+	"video2bas/json2bas"
+	v2btypes "video2bas/type"
+	"video2bas/video2color"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			runImport(os.Args[2:])
+			return
+		case "lint":
+			runLint(os.Args[2:])
+			return
+		case "render":
+			runRender(os.Args[2:])
+			return
+		}
+	}
 
 	videoPath := flag.String("viedo", "", "视频文件路径")
-	fps := flag.Int("fps", 10, "每秒帧数")
 	maxWidth := flag.Int("width", 96, "最大宽度")
 	colorCount := flag.Int("colors", 4, "颜色数量")
-	savePath := flag.String("output", "output/video", "输出文件路径")
-	maxFileSize := flag.Int("maxsize", 2*1024*1024, "单个输出文件最大尺寸，单位字节")
 	parallel := flag.Int("parallel", 4, "并行处理的最大协程数")
 	serial := flag.Bool("serial", false, "是否串行处理以最大程度减少内存使用")
+	minArea := flag.Int("minarea", 1, "颜色图层的最小像素数，低于该值的图层直接跳过")
+
+	trace := color2svg.DefaultTraceOptions()
+	turdSize := flag.Int("turdsize", trace.TurdSize, "忽略面积不超过该值的斑点")
+	turnPolicy := flag.String("turnpolicy", "minority", "路径转向策略：black/white/left/right/minority/majority/random")
+	alphaMax := flag.Float64("alphamax", trace.AlphaMax, "拐角平滑度，0 为全部折线")
+	optiCurve := flag.Bool("opticurve", trace.OptiCurve, "是否合并相邻贝塞尔曲线")
+	optTolerance := flag.Float64("opttolerance", trace.OptTolerance, "曲线合并容差")
+	threshold := flag.Int("threshold", int(trace.Threshold), "二值化阈值（0-255），低于该值视为前景")
+	tracer := flag.String("tracer", trace.Tracer, "描摹器：potrace（平滑曲线）或 polygon（像素多边形，适合像素画）")
+	snap := flag.Float64("snap", 0, "polygon 描摹器的拐角吸附网格（像素），大于 1 时把拐角吸附到该间距的网格上以去掉细小台阶并保持轴对齐，窄于网格的细节会被抹平")
+	framesIn := flag.String("frames-in", "", "从 JSONL 帧数据文件生成 BAS，不再处理视频")
+	budgetBytes := flag.Int64("budget", 0, "总字节数预算，大于 0 时抽样估算并自动选择精度、描摹参数、颜色数与帧率")
+	budgetChunks := flag.Int("chunks", 0, "分块数预算（每块不超过 -maxsize），可与 -budget 同时使用")
+	minFPS := flag.String("minfps", "", "预算模式允许降低到的最低帧率，默认为 -fps 的一半")
+	minColors := flag.Int("mincolors", 2, "预算模式允许降低到的最少颜色数")
+	samples := flag.Int("samples", 16, "预算模式从视频中抽取的样本帧数")
+
+	shared := addOutputFlags(flag.CommandLine, "output/video", color2svg.Unit, "像素", json2bas.DefaultStyle())
 
 	help := flag.Bool("help", false, "显示帮助信息")
 	flag.Parse()
@@ -22,16 +57,66 @@ func main() {
 		flag.Usage()
 		return
 	}
-	if *videoPath == "" {
+	if *videoPath == "" && *framesIn == "" {
 		flag.Usage()
 		return
 	}
 
+	opts, err := shared.options()
+	if err != nil {
+		log.Fatal(err)
+	}
+	policy, err := color2svg.ParseTurnPolicy(*turnPolicy)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *threshold < 0 || *threshold > 255 {
+		log.Fatalf("threshold out of range: %d", *threshold)
+	}
+	trace.TurdSize = *turdSize
+	trace.TurnPolicy = policy
+	trace.AlphaMax = *alphaMax
+	trace.OptiCurve = *optiCurve
+	trace.OptTolerance = *optTolerance
+	trace.Threshold = uint8(*threshold)
+	trace.Tracer = *tracer
+	trace.Snap = *snap
+	trace.Coords = opts.Coords
+	if _, err := color2svg.NewTracer(trace); err != nil {
+		log.Fatal(err)
+	}
+	opts.VideoPath = *videoPath
+	opts.MaxWidth = *maxWidth
+	opts.ColorCount = *colorCount
+	opts.Parallel = *parallel
+	opts.Split = video2color.SplitOptions{MinArea: *minArea}
+	opts.Trace = trace
+	opts.FramesIn = *framesIn
+
 	ctx := context.Background()
 
-	if *serial {
-		generateBasSerial(ctx, *videoPath, *fps, *maxWidth, *colorCount, *maxFileSize, *savePath)
+	if *budgetBytes > 0 || *budgetChunks > 0 {
+		if opts.VideoPath == "" || opts.FramesIn != "" {
+			log.Fatal("budget mode requires -viedo and cannot be used with -frames-in")
+		}
+		b := budgetOptions{Bytes: *budgetBytes, Chunks: *budgetChunks, MinFPS: opts.FPS.Div(2), MinColors: *minColors, Samples: *samples}
+		if *minFPS != "" {
+			if b.MinFPS, err = v2btypes.ParseRate(*minFPS); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if b.Samples < 1 {
+			log.Fatalf("samples out of range: %d", b.Samples)
+		}
+		fitBudget(ctx, &opts, b)
+	}
+
+	if opts.FramesIn != "" {
+		generateBasFromFrames(opts)
+	} else if *serial {
+		generateBasSerial(ctx, opts)
 	} else {
-		generateBasToFile(ctx, *videoPath, *fps, *maxWidth, *colorCount, *maxFileSize, *savePath, *parallel)
+		generateBasToFile(ctx, opts)
 	}
+	writeIDMap(opts)
 }
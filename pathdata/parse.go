@@ -0,0 +1,372 @@
+package pathdata
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	v2btypes "video2bas/type"
+)
+
+// Parse 解析 SVG 路径数据。H/V/Q/T/S/A 等命令统一转换为直线与三次贝塞尔曲线
+func Parse(d string) (v2btypes.Path, error) {
+	p := &parser{s: d}
+	var (
+		path     v2btypes.Path
+		sp       *v2btypes.SubPath
+		cur      v2btypes.Point
+		start    v2btypes.Point
+		lastCmd  byte
+		lastCtrl v2btypes.Point // 上一段的控制点，用于 S/T 的镜像
+	)
+
+	ensure := func() {
+		if sp == nil {
+			path.SubPaths = append(path.SubPaths, v2btypes.SubPath{Start: cur})
+			sp = &path.SubPaths[len(path.SubPaths)-1]
+		}
+	}
+	lineTo := func(pt v2btypes.Point) {
+		ensure()
+		sp.Segments = append(sp.Segments, v2btypes.Segment{Kind: v2btypes.SegLine, Pts: [3]v2btypes.Point{pt}})
+		cur = pt
+	}
+	cubicTo := func(c1, c2, pt v2btypes.Point) {
+		ensure()
+		sp.Segments = append(sp.Segments, v2btypes.Segment{Kind: v2btypes.SegCubic, Pts: [3]v2btypes.Point{c1, c2, pt}})
+		cur = pt
+	}
+
+	for {
+		p.skipSep()
+		if p.eof() {
+			break
+		}
+		cmd := p.s[p.i]
+		if isCommand(cmd) {
+			p.i++
+		} else if lastCmd != 0 && lastCmd != 'Z' && lastCmd != 'z' {
+			// 隐式重复上一条命令，M/m 之后重复的是 L/l
+			cmd = lastCmd
+			if cmd == 'M' {
+				cmd = 'L'
+			} else if cmd == 'm' {
+				cmd = 'l'
+			}
+		} else {
+			return path, fmt.Errorf("pathdata: unexpected %q at offset %d", p.s[p.i], p.i)
+		}
+
+		rel := cmd >= 'a' && cmd <= 'z'
+		off := func(x, y float64) v2btypes.Point {
+			if rel {
+				return v2btypes.Point{X: cur.X + x, Y: cur.Y + y}
+			}
+			return v2btypes.Point{X: x, Y: y}
+		}
+
+		switch cmd {
+		case 'M', 'm':
+			n, err := p.nums(2)
+			if err != nil {
+				return path, err
+			}
+			cur = off(n[0], n[1])
+			start = cur
+			sp = nil
+			ensure()
+			lastCtrl = cur
+		case 'L', 'l':
+			n, err := p.nums(2)
+			if err != nil {
+				return path, err
+			}
+			lineTo(off(n[0], n[1]))
+			lastCtrl = cur
+		case 'H', 'h':
+			n, err := p.nums(1)
+			if err != nil {
+				return path, err
+			}
+			x := n[0]
+			if rel {
+				x += cur.X
+			}
+			lineTo(v2btypes.Point{X: x, Y: cur.Y})
+			lastCtrl = cur
+		case 'V', 'v':
+			n, err := p.nums(1)
+			if err != nil {
+				return path, err
+			}
+			y := n[0]
+			if rel {
+				y += cur.Y
+			}
+			lineTo(v2btypes.Point{X: cur.X, Y: y})
+			lastCtrl = cur
+		case 'C', 'c':
+			n, err := p.nums(6)
+			if err != nil {
+				return path, err
+			}
+			c1, c2, pt := off(n[0], n[1]), off(n[2], n[3]), off(n[4], n[5])
+			cubicTo(c1, c2, pt)
+			lastCtrl = c2
+		case 'S', 's':
+			n, err := p.nums(4)
+			if err != nil {
+				return path, err
+			}
+			c1 := cur
+			if isOneOf(lastCmd, "CcSs") {
+				c1 = reflect(lastCtrl, cur)
+			}
+			c2, pt := off(n[0], n[1]), off(n[2], n[3])
+			cubicTo(c1, c2, pt)
+			lastCtrl = c2
+		case 'Q', 'q':
+			n, err := p.nums(4)
+			if err != nil {
+				return path, err
+			}
+			q, pt := off(n[0], n[1]), off(n[2], n[3])
+			c1, c2 := quadToCubic(cur, q, pt)
+			cubicTo(c1, c2, pt)
+			lastCtrl = q
+		case 'T', 't':
+			n, err := p.nums(2)
+			if err != nil {
+				return path, err
+			}
+			q := cur
+			if isOneOf(lastCmd, "QqTt") {
+				q = reflect(lastCtrl, cur)
+			}
+			pt := off(n[0], n[1])
+			c1, c2 := quadToCubic(cur, q, pt)
+			cubicTo(c1, c2, pt)
+			lastCtrl = q
+		case 'A', 'a':
+			n, err := p.arc()
+			if err != nil {
+				return path, err
+			}
+			pt := off(n[5], n[6])
+			for _, seg := range arcToCubics(cur, n[0], n[1], n[2], n[3] != 0, n[4] != 0, pt) {
+				cubicTo(seg[0], seg[1], seg[2])
+			}
+			cur = pt
+			lastCtrl = cur
+		case 'Z', 'z':
+			if sp != nil {
+				sp.Closed = true
+			}
+			cur = start
+			sp = nil
+			lastCtrl = cur
+		}
+		lastCmd = cmd
+	}
+	return path, nil
+}
+
+// Reformat 解析后按 opts 重新序列化，解析失败时原样返回
+func Reformat(d string, opts Options) string {
+	p, err := Parse(d)
+	if err != nil {
+		return d
+	}
+	return Format(p, opts)
+}
+
+type parser struct {
+	s string
+	i int
+}
+
+func (p *parser) eof() bool { return p.i >= len(p.s) }
+
+func (p *parser) skipSep() {
+	for !p.eof() {
+		switch p.s[p.i] {
+		case ' ', '\t', '\n', '\r', ',':
+			p.i++
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) nums(n int) ([]float64, error) {
+	out := make([]float64, n)
+	for k := range out {
+		v, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// arc 读取圆弧参数，两个标志位可以不带分隔符
+func (p *parser) arc() ([]float64, error) {
+	out := make([]float64, 7)
+	for k := range out {
+		if k == 3 || k == 4 {
+			p.skipSep()
+			if p.eof() || (p.s[p.i] != '0' && p.s[p.i] != '1') {
+				return nil, fmt.Errorf("pathdata: invalid arc flag at offset %d", p.i)
+			}
+			out[k] = float64(p.s[p.i] - '0')
+			p.i++
+			continue
+		}
+		v, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (p *parser) number() (float64, error) {
+	p.skipSep()
+	start := p.i
+	if !p.eof() && (p.s[p.i] == '+' || p.s[p.i] == '-') {
+		p.i++
+	}
+	digits, dot := false, false
+	for !p.eof() {
+		c := p.s[p.i]
+		if c >= '0' && c <= '9' {
+			digits = true
+		} else if c == '.' && !dot {
+			dot = true
+		} else {
+			break
+		}
+		p.i++
+	}
+	if !digits {
+		return 0, fmt.Errorf("pathdata: expected number at offset %d", start)
+	}
+	if !p.eof() && (p.s[p.i] == 'e' || p.s[p.i] == 'E') {
+		j := p.i + 1
+		if j < len(p.s) && (p.s[j] == '+' || p.s[j] == '-') {
+			j++
+		}
+		if j < len(p.s) && p.s[j] >= '0' && p.s[j] <= '9' {
+			for j < len(p.s) && p.s[j] >= '0' && p.s[j] <= '9' {
+				j++
+			}
+			p.i = j
+		}
+	}
+	return strconv.ParseFloat(p.s[start:p.i], 64)
+}
+
+func isCommand(c byte) bool {
+	return isOneOf(c, "MmLlHhVvCcSsQqTtAaZz")
+}
+
+func isOneOf(c byte, set string) bool {
+	for i := 0; i < len(set); i++ {
+		if set[i] == c {
+			return true
+		}
+	}
+	return false
+}
+
+func reflect(ctrl, about v2btypes.Point) v2btypes.Point {
+	return v2btypes.Point{X: 2*about.X - ctrl.X, Y: 2*about.Y - ctrl.Y}
+}
+
+func quadToCubic(p0, q, p1 v2btypes.Point) (v2btypes.Point, v2btypes.Point) {
+	return v2btypes.Point{X: p0.X + 2.0/3*(q.X-p0.X), Y: p0.Y + 2.0/3*(q.Y-p0.Y)},
+		v2btypes.Point{X: p1.X + 2.0/3*(q.X-p1.X), Y: p1.Y + 2.0/3*(q.Y-p1.Y)}
+}
+
+// arcToCubics 按 SVG 规范将椭圆弧转换为不超过 90° 的三次贝塞尔曲线段
+func arcToCubics(p0 v2btypes.Point, rx, ry, angle float64, large, sweep bool, p1 v2btypes.Point) [][3]v2btypes.Point {
+	if p0 == p1 {
+		return nil
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		return [][3]v2btypes.Point{{p0, p1, p1}}
+	}
+	phi := angle * math.Pi / 180
+	sinPhi, cosPhi := math.Sin(phi), math.Cos(phi)
+
+	dx, dy := (p0.X-p1.X)/2, (p0.Y-p1.Y)/2
+	x1 := cosPhi*dx + sinPhi*dy
+	y1 := -sinPhi*dx + cosPhi*dy
+
+	// 半径不足时等比放大
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 {
+		s := math.Sqrt(lambda)
+		rx, ry = rx*s, ry*s
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := 0.0
+	if den != 0 && num > 0 {
+		coef = math.Sqrt(num / den)
+	}
+	if large == sweep {
+		coef = -coef
+	}
+	cx1 := coef * rx * y1 / ry
+	cy1 := -coef * ry * x1 / rx
+	cx := cosPhi*cx1 - sinPhi*cy1 + (p0.X+p1.X)/2
+	cy := sinPhi*cx1 + cosPhi*cy1 + (p0.Y+p1.Y)/2
+
+	theta1 := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	dtheta := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx) - theta1
+	if sweep && dtheta < 0 {
+		dtheta += 2 * math.Pi
+	} else if !sweep && dtheta > 0 {
+		dtheta -= 2 * math.Pi
+	}
+
+	n := int(math.Ceil(math.Abs(dtheta) / (math.Pi / 2)))
+	if n == 0 {
+		return nil
+	}
+	step := dtheta / float64(n)
+	k := 4.0 / 3 * math.Tan(step/4)
+	point := func(t float64) (v2btypes.Point, v2btypes.Point) {
+		cosT, sinT := math.Cos(t), math.Sin(t)
+		pos := v2btypes.Point{
+			X: cx + rx*cosT*cosPhi - ry*sinT*sinPhi,
+			Y: cy + rx*cosT*sinPhi + ry*sinT*cosPhi,
+		}
+		deriv := v2btypes.Point{
+			X: -rx*sinT*cosPhi - ry*cosT*sinPhi,
+			Y: -rx*sinT*sinPhi + ry*cosT*cosPhi,
+		}
+		return pos, deriv
+	}
+
+	out := make([][3]v2btypes.Point, 0, n)
+	t := theta1
+	from, d0 := point(t)
+	for i := 0; i < n; i++ {
+		t += step
+		to, d1 := point(t)
+		if i == n-1 {
+			to = p1
+		}
+		out = append(out, [3]v2btypes.Point{
+			{X: from.X + k*d0.X, Y: from.Y + k*d0.Y},
+			{X: to.X - k*d1.X, Y: to.Y - k*d1.Y},
+			to,
+		})
+		from, d0 = to, d1
+	}
+	return out
+}
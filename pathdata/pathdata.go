@@ -0,0 +1,220 @@
+package pathdata
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	v2btypes "video2bas/type"
+)
+
+// Options 控制路径数据的序列化方式
+type Options struct {
+	Precision int  // 坐标保留的小数位数
+	Absolute  bool // 只使用 M、L、C、Z，拓扑相同的路径得到相同的命令序列，可用于 d 属性补间
+}
+
+// DefaultOptions 返回默认参数：坐标取整
+func DefaultOptions() Options {
+	return Options{Precision: 0}
+}
+
+// Format 将路径压缩为尽可能短的 SVG 路径数据：
+// 每段在绝对与相对命令中择短，水平/垂直直线使用 H/V，
+// 可复用上一段命令字母时省略字母，并省略不必要的分隔符与前导零。
+// opts.Absolute 时每段固定使用绝对命令，也不再把回到起点的直线交给 Z
+func Format(p v2btypes.Path, opts Options) string {
+	w := &writer{prec: opts.Precision, abs: opts.Absolute}
+	w.scale = math.Pow10(w.prec)
+
+	for _, sp := range p.SubPaths {
+		if len(sp.Segments) == 0 {
+			continue
+		}
+		start := w.snap(sp.Start)
+		w.emit(w.pick(
+			candidate{'M', []float64{start.X, start.Y}},
+			candidate{'m', []float64{start.X - w.cur.X, start.Y - w.cur.Y}},
+		))
+		w.cur = start
+		w.prevCtrl = nil
+
+		segs := sp.Segments
+		// 闭合时若最后一段是回到起点的直线，交给 Z 绘制
+		if sp.Closed && len(segs) > 0 && !w.abs {
+			last := segs[len(segs)-1]
+			if last.Kind == v2btypes.SegLine && w.snap(last.Pts[0]) == start {
+				segs = segs[:len(segs)-1]
+			}
+		}
+		for _, seg := range segs {
+			switch seg.Kind {
+			case v2btypes.SegLine:
+				w.line(w.snap(seg.Pts[0]))
+			case v2btypes.SegCubic:
+				w.cubic(w.snap(seg.Pts[0]), w.snap(seg.Pts[1]), w.snap(seg.Pts[2]))
+			}
+		}
+		if sp.Closed {
+			w.emit(candidate{cmd: 'z'})
+			w.cur = start
+			w.prevCtrl = nil
+		}
+	}
+	return w.sb.String()
+}
+
+// candidate 表示一种可能的命令写法
+type candidate struct {
+	cmd  byte
+	nums []float64
+}
+
+type writer struct {
+	sb       strings.Builder
+	prec     int
+	abs      bool // 只使用绝对命令
+	scale    float64
+	cur      v2btypes.Point
+	prevCtrl *v2btypes.Point // 上一段三次贝塞尔的第二控制点，用于 S/s
+	implicit byte            // 下一段可省略字母时对应的命令
+	lastNum  string          // 最后写出的数字，用于判断是否需要分隔符
+}
+
+// snap 将坐标对齐到精度网格，保证相对坐标不会累计误差
+func (w *writer) snap(p v2btypes.Point) v2btypes.Point {
+	return v2btypes.Point{X: w.round(p.X), Y: w.round(p.Y)}
+}
+
+func (w *writer) round(v float64) float64 {
+	return math.Round(v*w.scale) / w.scale
+}
+
+func (w *writer) line(p v2btypes.Point) {
+	dx, dy := w.round(p.X-w.cur.X), w.round(p.Y-w.cur.Y)
+	cands := []candidate{
+		{'L', []float64{p.X, p.Y}},
+		{'l', []float64{dx, dy}},
+	}
+	if dy == 0 {
+		cands = append(cands, candidate{'H', []float64{p.X}}, candidate{'h', []float64{dx}})
+	}
+	if dx == 0 {
+		cands = append(cands, candidate{'V', []float64{p.Y}}, candidate{'v', []float64{dy}})
+	}
+	w.emit(w.pick(cands...))
+	w.cur = p
+	w.prevCtrl = nil
+}
+
+func (w *writer) cubic(c1, c2, p v2btypes.Point) {
+	rel := func(q v2btypes.Point) (float64, float64) {
+		return w.round(q.X - w.cur.X), w.round(q.Y - w.cur.Y)
+	}
+	c1x, c1y := rel(c1)
+	c2x, c2y := rel(c2)
+	px, py := rel(p)
+	cands := []candidate{
+		{'C', []float64{c1.X, c1.Y, c2.X, c2.Y, p.X, p.Y}},
+		{'c', []float64{c1x, c1y, c2x, c2y, px, py}},
+	}
+	// 第一控制点恰为上一段第二控制点的镜像时可用 S/s
+	if w.prevCtrl != nil {
+		mirror := v2btypes.Point{X: w.round(2*w.cur.X - w.prevCtrl.X), Y: w.round(2*w.cur.Y - w.prevCtrl.Y)}
+		if mirror == c1 {
+			cands = append(cands,
+				candidate{'S', []float64{c2.X, c2.Y, p.X, p.Y}},
+				candidate{'s', []float64{c2x, c2y, px, py}},
+			)
+		}
+	}
+	w.emit(w.pick(cands...))
+	w.cur = p
+	w.prevCtrl = &c2
+}
+
+// pick 选出在当前状态下写出最短的写法；只使用绝对命令时取第一种（M、L、C）
+func (w *writer) pick(cands ...candidate) candidate {
+	if w.abs {
+		return cands[0]
+	}
+	best, bestLen := cands[0], -1
+	for _, c := range cands {
+		n := w.cost(c)
+		if bestLen < 0 || n < bestLen {
+			best, bestLen = c, n
+		}
+	}
+	return best
+}
+
+func (w *writer) cost(c candidate) int {
+	n := 0
+	last := w.lastNum
+	if c.cmd != w.implicit || len(c.nums) == 0 {
+		n++
+		last = ""
+	}
+	for _, v := range c.nums {
+		s := w.number(v)
+		if needSep(last, s) {
+			n++
+		}
+		n += len(s)
+		last = s
+	}
+	return n
+}
+
+func (w *writer) emit(c candidate) {
+	if c.cmd != w.implicit || len(c.nums) == 0 {
+		w.sb.WriteByte(c.cmd)
+		w.lastNum = ""
+	}
+	for _, v := range c.nums {
+		s := w.number(v)
+		if needSep(w.lastNum, s) {
+			w.sb.WriteByte(' ')
+		}
+		w.sb.WriteString(s)
+		w.lastNum = s
+	}
+	switch c.cmd {
+	case 'M':
+		w.implicit = 'L'
+	case 'm':
+		w.implicit = 'l'
+	case 'z', 'Z':
+		w.implicit = 0
+	default:
+		w.implicit = c.cmd
+	}
+}
+
+// number 输出最短的数字形式：去掉末尾零与前导零
+func (w *writer) number(v float64) string {
+	v = w.round(v)
+	if v == 0 {
+		return "0"
+	}
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if strings.HasPrefix(s, "0.") {
+		s = s[1:]
+	} else if strings.HasPrefix(s, "-0.") {
+		s = "-" + s[2:]
+	}
+	return s
+}
+
+// needSep 判断两个相邻数字之间是否需要空格
+func needSep(prev, next string) bool {
+	if prev == "" {
+		return false
+	}
+	if next[0] == '-' {
+		return false
+	}
+	if next[0] == '.' && strings.Contains(prev, ".") {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,192 @@
+package pathdata
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+	v2btypes "video2bas/type"
+)
+
+func mustParse(t *testing.T, d string) v2btypes.Path {
+	t.Helper()
+	p, err := Parse(d)
+	if err != nil {
+		t.Fatalf("parse %q: %v", d, err)
+	}
+	return p
+}
+
+// 各种写法的取舍：H/V、相对与绝对、S、隐式重复、分隔符与前导零
+func TestFormat(t *testing.T) {
+	for _, tc := range []struct {
+		d    string
+		opts Options
+		want string
+	}{
+		{"M10 10 L20 10 L20 20 L10 20 Z", Options{}, "M10 10H20V20H10z"},
+		{"M0 0 L10 0 L20 0 L30 5", Options{}, "M0 0H10 20L30 5"},
+		{"M0 0 C10 0 20 10 30 10 C40 10 50 0 60 0", Options{}, "M0 0C10 0 20 10 30 10S50 0 60 0"},
+		{"M100 100 L101 101 M50 50 L60 60", Options{}, "M100 100l1 1M50 50 60 60"},
+		{"M10 10 H20 V20 H10 Z M30 30 h5 v5 h-5 z", Options{}, "M10 10H20V20H10zM30 30h5v5H30z"},
+		{"M0.5 0.25 L0.75 -0.5", Options{Precision: 2}, "M.5.25.75-.5"},
+		{"M0.5 0.25 L0.75 -0.5", Options{Precision: 1}, "M.5.3.8-.5"},
+		{"M0.5 0.25 L0.75 -0.5", Options{}, "M1 0V-1"},
+		{"M10 10 L20 10 L20 20 L10 20 Z", Options{Absolute: true}, "M10 10 20 10 20 20 10 20z"},
+		{"M0 0 C10 0 20 10 30 10 C40 10 50 0 60 0", Options{Absolute: true}, "M0 0C10 0 20 10 30 10 40 10 50 0 60 0"},
+	} {
+		p := mustParse(t, tc.d)
+		if got := Format(p, tc.opts); got != tc.want {
+			t.Errorf("Format(%q, %+v) = %q, want %q", tc.d, tc.opts, got, tc.want)
+		}
+	}
+}
+
+// randomPath 生成带水平、垂直直线、可用 S 的平滑曲线以及闭合子路径的路径
+func randomPath(r *rand.Rand, subPaths, segs int) v2btypes.Path {
+	coord := func() float64 { return math.Round(r.Float64()*20000) / 100 }
+	var p v2btypes.Path
+	for i := 0; i < subPaths; i++ {
+		sp := v2btypes.SubPath{Start: v2btypes.Point{X: coord(), Y: coord()}, Closed: i%2 == 0}
+		cur, prevCtrl := sp.Start, (*v2btypes.Point)(nil)
+		for j := 0; j < segs; j++ {
+			next := v2btypes.Point{X: coord(), Y: coord()}
+			switch r.Intn(5) {
+			case 0:
+				next.Y = cur.Y
+				fallthrough
+			case 1:
+				sp.Segments = append(sp.Segments, v2btypes.Segment{Kind: v2btypes.SegLine, Pts: [3]v2btypes.Point{next}})
+				prevCtrl = nil
+			case 2:
+				next.X = cur.X
+				sp.Segments = append(sp.Segments, v2btypes.Segment{Kind: v2btypes.SegLine, Pts: [3]v2btypes.Point{next}})
+				prevCtrl = nil
+			default:
+				c1 := v2btypes.Point{X: coord(), Y: coord()}
+				if prevCtrl != nil && r.Intn(2) == 0 {
+					c1 = v2btypes.Point{X: 2*cur.X - prevCtrl.X, Y: 2*cur.Y - prevCtrl.Y}
+				}
+				c2 := v2btypes.Point{X: coord(), Y: coord()}
+				sp.Segments = append(sp.Segments, v2btypes.Segment{Kind: v2btypes.SegCubic, Pts: [3]v2btypes.Point{c1, c2, next}})
+				prevCtrl = &c2
+			}
+			cur = next
+		}
+		if sp.Closed {
+			sp.Segments = append(sp.Segments, v2btypes.Segment{Kind: v2btypes.SegLine, Pts: [3]v2btypes.Point{sp.Start}})
+		}
+		p.SubPaths = append(p.SubPaths, sp)
+	}
+	return p
+}
+
+// trimClose 去掉闭合子路径末尾回到起点的直线，Format 会把它交给 Z
+func trimClose(sp v2btypes.SubPath, tol float64) []v2btypes.Segment {
+	segs := sp.Segments
+	if n := len(segs); sp.Closed && n > 0 && segs[n-1].Kind == v2btypes.SegLine &&
+		math.Abs(segs[n-1].Pts[0].X-sp.Start.X) <= tol && math.Abs(segs[n-1].Pts[0].Y-sp.Start.Y) <= tol {
+		segs = segs[:n-1]
+	}
+	return segs
+}
+
+// 解析压缩结果得到的每个点与原路径的误差不超过半个精度单位，相对坐标不累计误差；长度不超过未压缩的写法
+func TestFormatRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for prec := 0; prec <= 3; prec++ {
+		tol := 0.5*math.Pow10(-prec) + 1e-9
+		for n := 0; n < 50; n++ {
+			want := randomPath(r, 3, 20)
+			for _, abs := range []bool{false, true} {
+				d := Format(want, Options{Precision: prec, Absolute: abs})
+				if raw := want.SVG(prec); len(d) > len(raw) && !abs {
+					t.Errorf("prec %d: formatted %d bytes, plain SVG %d bytes", prec, len(d), len(raw))
+				}
+				got := mustParse(t, d)
+				if len(got.SubPaths) != len(want.SubPaths) {
+					t.Fatalf("prec %d: %d subpaths, want %d\n%s", prec, len(got.SubPaths), len(want.SubPaths), d)
+				}
+				for i, sp := range want.SubPaths {
+					g := got.SubPaths[i]
+					ws, gs := trimClose(sp, tol), trimClose(g, 0)
+					if g.Closed != sp.Closed || len(gs) != len(ws) {
+						t.Fatalf("prec %d subpath %d: closed %v with %d segments, want %v with %d\n%s", prec, i, g.Closed, len(gs), sp.Closed, len(ws), d)
+					}
+					near := func(a, b v2btypes.Point) bool {
+						return math.Abs(a.X-b.X) <= tol && math.Abs(a.Y-b.Y) <= tol
+					}
+					if !near(g.Start, sp.Start) {
+						t.Fatalf("prec %d subpath %d: start %v, want %v", prec, i, g.Start, sp.Start)
+					}
+					for j, seg := range ws {
+						if gs[j].Kind != seg.Kind {
+							t.Fatalf("prec %d subpath %d segment %d: kind %v, want %v\n%s", prec, i, j, gs[j].Kind, seg.Kind, d)
+						}
+						for k := range seg.Pts {
+							if !near(gs[j].Pts[k], seg.Pts[k]) {
+								t.Fatalf("prec %d subpath %d segment %d: point %v, want %v\n%s", prec, i, j, gs[j].Pts[k], seg.Pts[k], d)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// commands 返回路径数据中去掉数字后的命令骨架，数字以 # 表示
+func commands(d string) string {
+	var sb strings.Builder
+	inNum := false
+	for i := 0; i < len(d); i++ {
+		c := d[i]
+		switch {
+		case isCommand(c):
+			sb.WriteByte(c)
+			inNum = false
+		case c == ' ' || c == ',':
+			inNum = false
+		case c == '-' || (c == '.' && inNum && strings.Contains(lastNumber(d[:i]), ".")):
+			sb.WriteByte('#')
+			inNum = true
+		case !inNum:
+			sb.WriteByte('#')
+			inNum = true
+		}
+	}
+	return sb.String()
+}
+
+// lastNumber 返回 s 末尾正在书写的数字
+func lastNumber(s string) string {
+	i := len(s)
+	for i > 0 && (s[i-1] == '.' || s[i-1] >= '0' && s[i-1] <= '9') {
+		i--
+	}
+	return s[i:]
+}
+
+// Absolute 模式下拓扑相同的路径得到相同的命令序列，只使用 M、L、C、Z
+func TestFormatAbsoluteTopology(t *testing.T) {
+	base := randomPath(rand.New(rand.NewSource(2)), 3, 20)
+	r := rand.New(rand.NewSource(3))
+	for prec := 0; prec <= 2; prec++ {
+		opts := Options{Precision: prec, Absolute: true}
+		want := commands(Format(base, opts))
+		if strings.ContainsAny(want, "HVSQTAhvsqtalmc") {
+			t.Fatalf("absolute output uses relative or shorthand commands: %s", want)
+		}
+		for n := 0; n < 20; n++ {
+			moved := base.Transform(func(p v2btypes.Point) v2btypes.Point {
+				return v2btypes.Point{X: p.X + r.Float64()*50 - 25, Y: p.Y + r.Float64()*50 - 25}
+			})
+			if !moved.SameTopology(base) {
+				t.Fatal("transform changed topology")
+			}
+			if got := commands(Format(moved, opts)); got != want {
+				t.Fatalf("prec %d: commands differ\n got %s\nwant %s", prec, got, want)
+			}
+		}
+	}
+}
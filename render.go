@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"video2bas/basgen"
+	"video2bas/basrender"
+	v2btypes "video2bas/type"
+)
+
+// runRender 处理 render 子命令：在本地将 BAS 分块渲染为 PNG 预览
+func runRender(args []string) {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	def := basrender.DefaultOptions()
+	width := fs.Int("width", def.Width, "画布宽度")
+	height := fs.Int("height", def.Height, "画布高度")
+	background := fs.String("background", v2btypes.HexColor(def.Background), "背景颜色 RRGGBB")
+	at := fs.Int64("at", -1, "只渲染该时刻（毫秒）的单帧，输出到 -output 指定的文件")
+	fps := fs.String("fps", "10", "渲染序列的帧率")
+	from := fs.Int64("from", 0, "序列开始时间（毫秒）")
+	to := fs.Int64("to", -1, "序列结束时间（毫秒），默认到最后一个动画结束")
+	output := fs.String("output", "output/render", "输出路径：单帧为文件名，序列为文件名前缀")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: video2bas render [flags] <file | dir | output prefix>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	bg, err := v2btypes.ParseHexColor(*background)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := basrender.Options{Width: *width, Height: *height, Background: bg}
+	if opts.Width <= 0 || opts.Height <= 0 {
+		log.Fatalf("invalid canvas size %dx%d", opts.Width, opts.Height)
+	}
+
+	scene := basrender.NewScene()
+	for _, arg := range fs.Args() {
+		names, err := chunkFiles(arg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, name := range names {
+			b, err := os.ReadFile(name)
+			if err != nil {
+				log.Fatal(err)
+			}
+			nodes, err := basgen.Parse(string(b))
+			if err != nil {
+				log.Fatalf("%s:%v", name, err)
+			}
+			if err := scene.Add(name, nodes, basgen.ChunkStart(string(b))); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	if *at >= 0 {
+		ensureOutputDir(*output)
+		writePNG(*output, scene, *at, opts)
+		return
+	}
+
+	rate, err := v2btypes.ParseRate(*fps)
+	if err != nil {
+		log.Fatal(err)
+	}
+	end := *to
+	if end < 0 {
+		end = scene.Duration()
+	}
+	ensureOutputDir(*output)
+	count := 0
+	for i := 0; ; i++ {
+		t := *from + rate.FrameStart(i)
+		if t > end {
+			break
+		}
+		writePNG(fmt.Sprintf("%s_%05d.png", *output, i), scene, t, opts)
+		count++
+	}
+	log.Printf("Rendered %d frames (%dms-%dms) to %s_*.png", count, *from, end, *output)
+}
+
+func writePNG(name string, scene *basrender.Scene, t int64, opts basrender.Options) {
+	f, err := os.Create(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := png.Encode(f, scene.Render(t, opts)); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"video2bas/json2bas"
+)
+
+// styleFlags 注册对象样式相关的命令行参数，命令行显式给出的值覆盖配置文件
+type styleFlags struct {
+	fs          *flag.FlagSet
+	def         json2bas.Style
+	file        *string
+	x, y        *string
+	size        *string
+	height      *string
+	anchor      *string
+	borderWidth *float64
+	borderColor *string
+	opacity     *float64
+	zIndex      *int
+}
+
+// addStyleFlags 注册样式参数，def 为未给出配置文件与参数时的样式
+func addStyleFlags(fs *flag.FlagSet, def json2bas.Style) *styleFlags {
+	return &styleFlags{
+		fs:          fs,
+		def:         def,
+		file:        fs.String("style", "", "样式配置文件（JSON），命令行参数优先"),
+		x:           fs.String("x", "", "对象位置 x，数值或百分比"),
+		y:           fs.String("y", "", "对象位置 y，数值或百分比"),
+		size:        fs.String("size", def.Width.String(), "对象宽度，数值或百分比"),
+		height:      fs.String("height", "", "对象高度，数值或百分比，为空时按 viewBox 比例"),
+		anchor:      fs.String("anchor", "", "锚点 \"x,y\"，取值 0-1"),
+		borderWidth: fs.Float64("borderwidth", def.BorderWidth, "描边宽度，0 为无描边"),
+		borderColor: fs.String("bordercolor", "", "描边颜色 RRGGBB，为空时与填充色相同，none 为无描边"),
+		opacity:     fs.Float64("opacity", def.Opacity, "整体不透明度（0-1）"),
+		zIndex:      fs.Int("zindex", 0, "对象层级 zIndex"),
+	}
+}
+
+// Style 合并配置文件与命令行参数
+func (f *styleFlags) Style() (*json2bas.Style, error) {
+	style := f.def
+	if *f.file != "" {
+		b, err := os.ReadFile(*f.file)
+		if err != nil {
+			return nil, err
+		}
+		if style, err = json2bas.ParseStyleWithDefault(b, f.def); err != nil {
+			return nil, fmt.Errorf("%s: %w", *f.file, err)
+		}
+	}
+	var err error
+	noBorder := false
+	f.fs.Visit(func(fl *flag.Flag) {
+		if err != nil {
+			return
+		}
+		switch fl.Name {
+		case "x":
+			style.X, err = json2bas.ParseLength(*f.x)
+		case "y":
+			style.Y, err = json2bas.ParseLength(*f.y)
+		case "size":
+			style.Width, err = json2bas.ParseLength(*f.size)
+		case "height":
+			style.Height, err = json2bas.ParseLength(*f.height)
+		case "anchor":
+			style.AnchorX, style.AnchorY, err = parseAnchor(*f.anchor)
+		case "borderwidth":
+			style.BorderWidth = *f.borderWidth
+		case "bordercolor":
+			if strings.EqualFold(*f.borderColor, "none") {
+				noBorder = true
+			} else {
+				style.BorderColor = *f.borderColor
+			}
+		case "opacity":
+			style.Opacity = *f.opacity
+		case "zindex":
+			z := *f.zIndex
+			style.ZIndex = &z
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Visit 按参数名顺序调用，none 须在 -borderwidth 之后生效
+	if noBorder {
+		style.BorderWidth = 0
+	}
+	return &style, style.Validate()
+}
+
+func parseAnchor(s string) (*float64, *float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return nil, nil, fmt.Errorf("invalid anchor %q, expected \"x,y\"", s)
+	}
+	var v [2]float64
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid anchor %q", s)
+		}
+		v[i] = f
+	}
+	return &v[0], &v[1], nil
+}
@@ -0,0 +1,158 @@
+package svg2json
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	v2btypes "video2bas/type"
+)
+
+// SVGFile 表示目录中按编号排列的一帧 SVG
+type SVGFile struct {
+	Name   string // 文件名，不含目录
+	Number int    // 文件名中的帧编号
+	Data   string
+}
+
+// FrameTiming 表示一帧的显示区间，单位毫秒
+type FrameTiming struct {
+	Start, End int64
+}
+
+var frameNumberRe = regexp.MustCompile(`(\d+)\D*$`)
+
+// ReadSVGDir 读取目录下所有 .svg 文件，按文件名中最后一组数字排序
+func ReadSVGDir(dir string) ([]SVGFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []SVGFile
+	seen := make(map[int]string)
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), ".svg") {
+			continue
+		}
+		base := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+		m := frameNumberRe.FindStringSubmatch(base)
+		if m == nil {
+			return nil, fmt.Errorf("%s: no frame number in file name", e.Name())
+		}
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		if prev, ok := seen[n]; ok {
+			return nil, fmt.Errorf("%s: frame number %d already used by %s", e.Name(), n, prev)
+		}
+		seen[n] = e.Name()
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, SVGFile{Name: e.Name(), Number: n, Data: string(data)})
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s: no svg files", dir)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Number < files[j].Number })
+	return files, nil
+}
+
+// ReadTimingFile 读取时间轴文件。每行为 "<文件名> <开始毫秒> [结束毫秒]"，# 开头为注释
+func ReadTimingFile(path string) (map[string]FrameTiming, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	timings := make(map[string]FrameTiming)
+	sc := bufio.NewScanner(f)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("%s:%d: expected \"<file> <start> [end]\"", path, line)
+		}
+		var t FrameTiming
+		if t.Start, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if len(fields) == 3 {
+			if t.End, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+			if t.End <= t.Start {
+				return nil, fmt.Errorf("%s:%d: end %d not after start %d", path, line, t.End, t.Start)
+			}
+		}
+		timings[fields[0]] = t
+	}
+	return timings, sc.Err()
+}
+
+// ResolveTimings 为每个文件确定显示区间：未列出的文件按帧率排布，
+// 缺少结束时间的帧持续到下一帧开始，最后一帧持续一个帧间隔
+func ResolveTimings(files []SVGFile, timings map[string]FrameTiming, rate v2btypes.Rate) ([]FrameTiming, error) {
+	out := make([]FrameTiming, len(files))
+	for i, f := range files {
+		t, ok := timings[f.Name]
+		if !ok {
+			if len(timings) > 0 {
+				return nil, fmt.Errorf("%s: missing from timing file", f.Name)
+			}
+			t.Start = rate.FrameStart(i)
+		}
+		out[i] = t
+	}
+	for i := range out {
+		if out[i].End != 0 {
+			continue
+		}
+		if i+1 < len(out) && out[i+1].Start > out[i].Start {
+			out[i].End = out[i+1].Start
+		} else {
+			out[i].End = out[i].Start + rate.FrameEnd(i) - rate.FrameStart(i)
+		}
+	}
+	return out, nil
+}
+
+// ImportFrame 将一份外部绘制的 SVG 解析为 FrameData，未指定填充色的图形按 SVG 默认的黑色处理；
+// 没有可绘制图形的空白帧得到不含图层的 FrameData
+func ImportFrame(index int, svg string, timing FrameTiming) (v2btypes.FrameData, error) {
+	return ImportFrameWithOptions(index, svg, timing, v2btypes.Coords{})
+}
+
+// ImportFrameWithOptions 与 ImportFrame 相同，并将坐标映射到 coords 指定的坐标空间
+func ImportFrameWithOptions(index int, svg string, timing FrameTiming, coords v2btypes.Coords) (v2btypes.FrameData, error) {
+	shapes, svgBox, err := ParseSVG(svg)
+	if err != nil {
+		return v2btypes.FrameData{}, err
+	}
+	vb, toViewBox := coords.Map(svgBox)
+	fd := v2btypes.FrameData{
+		Version:    v2btypes.FrameDataVersion,
+		FrameIndex: index,
+		ViewBox:    vb,
+		Layers:     appendShapes(nil, shapes, color.RGBA{A: 255}),
+	}
+	for i := range fd.Layers {
+		fd.Layers[i].Start, fd.Layers[i].End = timing.Start, timing.End
+		path := fd.Layers[i].Path.Transform(toViewBox)
+		fd.Layers[i].Path = &path
+	}
+	return fd, nil
+}
@@ -0,0 +1,21 @@
+package svg2json
+
+import (
+	"testing"
+	v2btypes "video2bas/type"
+)
+
+// 没有可绘制图形的帧得到空的图层列表，viewBox 照常映射
+func TestImportBlankFrame(t *testing.T) {
+	fd, err := ImportFrameWithOptions(3, `<svg viewBox="0 0 200 100"><g fill="red"></g></svg>`,
+		FrameTiming{Start: 300, End: 400}, v2btypes.Coords{Scale: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fd.Layers) != 0 {
+		t.Errorf("got %d layers, want none", len(fd.Layers))
+	}
+	if fd.FrameIndex != 3 || fd.ViewBox.W != 400 || fd.ViewBox.H != 200 {
+		t.Errorf("got frame %d with viewBox %+v, want frame 3 with 400x200", fd.FrameIndex, fd.ViewBox)
+	}
+}
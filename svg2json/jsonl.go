@@ -0,0 +1,88 @@
+package svg2json
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+// FillPathData 为仅有几何数据的图层生成 PathData，返回新的 FrameData
+func FillPathData(fd v2btypes.FrameData, opts pathdata.Options) v2btypes.FrameData {
+	layers := make([]v2btypes.Layer, len(fd.Layers))
+	for i, l := range fd.Layers {
+		if l.PathData == "" && l.Path != nil {
+			l.PathData = pathdata.Format(*l.Path, opts)
+		}
+		layers[i] = l
+	}
+	fd.Layers = layers
+	fd.Version = v2btypes.FrameDataVersion
+	return fd
+}
+
+// FrameEncoder 以 JSONL 格式逐帧写出 FrameData，每行一帧
+type FrameEncoder struct {
+	enc  *json.Encoder
+	opts pathdata.Options
+}
+
+// NewFrameEncoder 创建写入 w 的编码器，opts 控制几何数据序列化为 PathData 的精度
+func NewFrameEncoder(w io.Writer, opts pathdata.Options) *FrameEncoder {
+	return &FrameEncoder{enc: json.NewEncoder(w), opts: opts}
+}
+
+// Encode 写出一帧
+func (e *FrameEncoder) Encode(fd v2btypes.FrameData) error {
+	return e.enc.Encode(FillPathData(fd, e.opts))
+}
+
+// FrameDecoder 逐行读取 JSONL 格式的 FrameData
+type FrameDecoder struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+// NewFrameDecoder 创建从 r 读取的解码器
+func NewFrameDecoder(r io.Reader) *FrameDecoder {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 256*1024*1024)
+	return &FrameDecoder{sc: sc}
+}
+
+// Decode 读取下一帧并解析路径数据，读完时返回 io.EOF
+func (d *FrameDecoder) Decode() (v2btypes.FrameData, error) {
+	for d.sc.Scan() {
+		d.line++
+		b := d.sc.Bytes()
+		if len(bytes.TrimSpace(b)) == 0 {
+			continue
+		}
+		var fd v2btypes.FrameData
+		if err := json.Unmarshal(b, &fd); err != nil {
+			return fd, fmt.Errorf("line %d: %w", d.line, err)
+		}
+		if fd.Version < 1 || fd.Version > v2btypes.FrameDataVersion {
+			return fd, fmt.Errorf("line %d: unsupported FrameData version %d", d.line, fd.Version)
+		}
+		for i := range fd.Layers {
+			l := &fd.Layers[i]
+			if l.Path != nil {
+				continue
+			}
+			path, err := pathdata.Parse(l.PathData)
+			if err != nil {
+				return fd, fmt.Errorf("line %d layer %d: %w", d.line, i, err)
+			}
+			l.Path = &path
+		}
+		return fd, nil
+	}
+	if err := d.sc.Err(); err != nil {
+		return v2btypes.FrameData{}, err
+	}
+	return v2btypes.FrameData{}, io.EOF
+}
@@ -0,0 +1,97 @@
+package svg2json
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+// 写出再读回的帧保留全部字段，几何数据序列化为 PathData 后重新解析
+func TestFrameJSONLRoundTrip(t *testing.T) {
+	square, err := pathdata.Parse("M1.5 1.5H10.5V10.5H1.5Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := []v2btypes.FrameData{
+		{
+			FrameIndex: 0,
+			ViewBox:    v2btypes.ViewBox{W: 160, H: 90},
+			Skipped:    2,
+			Layers: []v2btypes.Layer{
+				{Color: "FF0000", Z: 0, Alpha: 1, Path: &square},
+				{Color: "00FF00", Z: 3, Alpha: 0.5, PathData: "M0 0h5v5z", Start: 100, End: 250},
+			},
+		},
+		{FrameIndex: 1, ViewBox: v2btypes.ViewBox{W: 160, H: 90}},
+	}
+	var buf bytes.Buffer
+	enc := NewFrameEncoder(&buf, pathdata.Options{Precision: 1})
+	for _, fd := range frames {
+		if err := enc.Encode(fd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := strings.Count(buf.String(), "\n"); n != len(frames) {
+		t.Fatalf("wrote %d lines, want %d", n, len(frames))
+	}
+
+	dec := NewFrameDecoder(&buf)
+	for i, want := range frames {
+		got, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if got.Version != v2btypes.FrameDataVersion || got.FrameIndex != want.FrameIndex ||
+			got.ViewBox != want.ViewBox || got.Skipped != want.Skipped || len(got.Layers) != len(want.Layers) {
+			t.Fatalf("frame %d: got %+v, want %+v", i, got, want)
+		}
+		for j, l := range got.Layers {
+			w := want.Layers[j]
+			if l.Color != w.Color || l.Z != w.Z || l.Alpha != w.Alpha || l.Start != w.Start || l.End != w.End {
+				t.Errorf("frame %d layer %d: got %+v, want %+v", i, j, l, w)
+			}
+			if l.Path == nil {
+				t.Fatalf("frame %d layer %d: path not parsed", i, j)
+			}
+			if w.Path != nil && l.Path.SVG(2) != w.Path.SVG(2) {
+				t.Errorf("frame %d layer %d: path %s, want %s", i, j, l.Path.SVG(2), w.Path.SVG(2))
+			}
+		}
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("after last frame: %v, want io.EOF", err)
+	}
+}
+
+// 缺省的 alpha 补为 1，空行跳过，不支持的版本与无效的路径报告行号
+func TestFrameDecoder(t *testing.T) {
+	dec := NewFrameDecoder(strings.NewReader("\n" + `{"version":1,"frameIndex":4,"layers":[{"color":"FFFFFF","pathdata":"M0 0H1V1z","z":0}]}` + "\n"))
+	fd, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fd.FrameIndex != 4 || len(fd.Layers) != 1 || fd.Layers[0].Alpha != 1 {
+		t.Errorf("got %+v, want frame 4 with one opaque layer", fd)
+	}
+
+	for _, tc := range []struct {
+		input, err string
+	}{
+		{`{"version":2}`, "line 1: unsupported FrameData version 2"},
+		{`{"layers":[]}`, "line 1: unsupported FrameData version 0"},
+		{"{\"version\":1}\n{\"version\":1,\"layers\":[{\"pathdata\":\"M0 0Q\"}]}", "line 2 layer 0"},
+		{`{"version":1,`, "line 1"},
+	} {
+		dec := NewFrameDecoder(strings.NewReader(tc.input))
+		var err error
+		for err == nil {
+			_, err = dec.Decode()
+		}
+		if err == io.EOF || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%q: got error %v, want %q", tc.input, err, tc.err)
+		}
+	}
+}
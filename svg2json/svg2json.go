@@ -2,11 +2,9 @@ package svg2json
 
 import (
 	"encoding/json"
-	"encoding/xml"
-	"fmt"
 	"image/color"
-	"strings"
 	"sync"
+	"video2bas/pathdata"
 	v2btypes "video2bas/type"
 )
 
@@ -66,57 +64,74 @@ func ParseAllFrameWithParallelProgress(frames []v2btypes.FrameSVG, parallel int,
 	return results
 }
 
-// ParseFrame 解析单帧 SVG
+// ParseFrame 解析单帧。带几何数据的图层直接沿用，仅有 SVG 文本的图层才解析文本：
+// 文本中显式指定了填充色的图形使用自身颜色，否则使用图层颜色；相邻同色图形合并为一个图层。
+// 图层的 Z 取调色板序号，某种颜色在本帧缺席时其余颜色的槽位不变
 func ParseFrame(frame v2btypes.FrameSVG) v2btypes.FrameData {
-	result := make([]map[string]string, 0, len(frame.Layers))
+	var layers []v2btypes.Layer
+	skipped := frame.Skipped
+	vb := frame.ViewBox
 
 	for _, layer := range frame.Layers {
-		paths := extractPaths(layer.SVGData)
-		data := map[string]string{
-			"color":    fmt.Sprintf("%s", toHex(layer.Color)),
-			"pathdata": strings.Join(paths, " "),
+		if layer.SVGData == "" || len(layer.Path.SubPaths) > 0 {
+			if layer.Path.Empty() {
+				skipped++
+				continue
+			}
+			path := layer.Path
+			layers = append(layers, v2btypes.Layer{
+				Color: v2btypes.HexColor(layer.Color),
+				Z:     layer.ColorIndex,
+				Alpha: 1,
+				Path:  &path,
+			})
+			continue
+		}
+		shapes, svgBox, err := ParseSVG(layer.SVGData)
+		if err != nil || len(shapes) == 0 {
+			skipped++
+			continue
+		}
+		if vb.W == 0 || vb.H == 0 {
+			vb = svgBox
+		}
+		first := len(layers)
+		layers = appendShapes(layers, shapes, layer.Color)
+		for i := first; i < len(layers); i++ {
+			layers[i].Z = layer.ColorIndex
 		}
-		result = append(result, data)
 	}
 
 	return v2btypes.FrameData{
+		Version:    v2btypes.FrameDataVersion,
 		FrameIndex: frame.FrameIndex,
-		Data:       result,
+		Layers:     layers,
+		ViewBox:    vb,
+		Skipped:    skipped,
 	}
 }
 
-func toHex(c color.RGBA) string {
-	return fmt.Sprintf("%02X%02X%02X", c.R, c.G, c.B)
+// appendShapes 将一份 SVG 中的图形追加为图层：未指定填充色的图形使用 fill，相邻同色图形合并
+func appendShapes(layers []v2btypes.Layer, shapes []Shape, fill color.RGBA) []v2btypes.Layer {
+	first := len(layers)
+	for _, shape := range shapes {
+		c := fill
+		if shape.HasFill {
+			c = shape.Fill
+		}
+		hex := v2btypes.HexColor(c)
+		if n := len(layers); n > first && layers[n-1].Color == hex {
+			layers[n-1].Path.SubPaths = append(layers[n-1].Path.SubPaths, shape.Path.SubPaths...)
+			continue
+		}
+		path := shape.Path
+		layers = append(layers, v2btypes.Layer{Color: hex, Z: len(layers), Alpha: 1, Path: &path})
+	}
+	return layers
 }
 
 // ParseFrameJSON 返回 JSON 字符串
 func ParseFrameJSON(frame v2btypes.FrameSVG) ([]byte, error) {
-	fd := ParseFrame(frame)
+	fd := FillPathData(ParseFrame(frame), pathdata.DefaultOptions())
 	return json.MarshalIndent([]v2btypes.FrameData{fd}, "", "  ")
 }
-
-// extractPaths 从 SVG 字符串中递归提取所有 <path> 的 d 属性
-func extractPaths(svgStr string) []string {
-	decoder := xml.NewDecoder(strings.NewReader(svgStr))
-	paths := []string{}
-
-	for {
-		tok, err := decoder.Token()
-		if err != nil {
-			break
-		}
-
-		switch se := tok.(type) {
-		case xml.StartElement:
-			if se.Name.Local == "path" {
-				for _, attr := range se.Attr {
-					if attr.Name.Local == "d" {
-						paths = append(paths, attr.Value)
-					}
-				}
-			}
-		}
-	}
-
-	return paths
-}
@@ -0,0 +1,57 @@
+package svg2json
+
+import (
+	"image/color"
+	"testing"
+	"video2bas/json2bas"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+// tracedFrame 模拟描摹结果：palette 中未出现在 present 里的颜色在本帧缺席
+func tracedFrame(t *testing.T, index int, palette []color.RGBA, present ...int) v2btypes.FrameSVG {
+	t.Helper()
+	frame := v2btypes.FrameSVG{FrameIndex: index, ViewBox: v2btypes.ViewBox{W: 100, H: 100}}
+	for _, i := range present {
+		path, err := pathdata.Parse("M0 0H10V10H0z")
+		if err != nil {
+			t.Fatal(err)
+		}
+		path = path.Transform(func(p v2btypes.Point) v2btypes.Point {
+			return v2btypes.Point{X: p.X + float64(20*i), Y: p.Y}
+		})
+		frame.Layers = append(frame.Layers, v2btypes.LayerSVG{ColorIndex: i, Color: palette[i], Path: path})
+	}
+	return frame
+}
+
+// 某种颜色在一帧中缺席时，其余颜色的 Z 不变，增量模式仍能复用它们的对象
+func TestParseFrameKeepsPaletteSlots(t *testing.T) {
+	palette := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}
+	first := ParseFrame(tracedFrame(t, 0, palette, 0, 1, 2))
+	second := ParseFrame(tracedFrame(t, 1, palette, 0, 2))
+
+	var zs []int
+	for _, l := range second.Layers {
+		zs = append(zs, l.Z)
+	}
+	if len(zs) != 2 || zs[0] != 0 || zs[1] != 2 {
+		t.Fatalf("second frame Z = %v, want [0 2]", zs)
+	}
+
+	for _, strategy := range []string{json2bas.StrategyFrame, json2bas.StrategyPool} {
+		g, err := json2bas.NewGenerator(strategy, json2bas.Options{
+			ViewBox: first.ViewBox,
+			Rate:    v2btypes.Rate{Num: 10, Den: 1},
+			Path:    pathdata.DefaultOptions(),
+			Delta:   true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Frame(first)
+		if _, stats := g.Frame(second); stats.Reused != 2 || stats.Layers != 0 {
+			t.Errorf("%s: second frame reused %d and declared %d layers, want 2 and 0", strategy, stats.Reused, stats.Layers)
+		}
+	}
+}
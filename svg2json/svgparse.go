@@ -0,0 +1,387 @@
+package svg2json
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"video2bas/pathdata"
+	v2btypes "video2bas/type"
+)
+
+// Shape 表示 SVG 中一个需要填充的图形，坐标已应用所有祖先元素的 transform
+type Shape struct {
+	Fill    color.RGBA
+	HasFill bool // 是否显式指定了填充色；未指定时 SVG 默认填充黑色
+	Path    v2btypes.Path
+}
+
+// matrix 为二维仿射变换 [a c e; b d f]
+type matrix struct {
+	a, b, c, d, e, f float64
+}
+
+var identity = matrix{a: 1, d: 1}
+
+// mul 返回 m × n，即先应用 n 再应用 m
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		a: m.a*n.a + m.c*n.b,
+		b: m.b*n.a + m.d*n.b,
+		c: m.a*n.c + m.c*n.d,
+		d: m.b*n.c + m.d*n.d,
+		e: m.a*n.e + m.c*n.f + m.e,
+		f: m.b*n.e + m.d*n.f + m.f,
+	}
+}
+
+func (m matrix) apply(p v2btypes.Point) v2btypes.Point {
+	return v2btypes.Point{X: m.a*p.X + m.c*p.Y + m.e, Y: m.b*p.X + m.d*p.Y + m.f}
+}
+
+// elemState 为元素继承下来的状态
+type elemState struct {
+	m       matrix
+	fill    string
+	display bool
+}
+
+// 这些元素的内容不直接渲染
+var skippedElements = map[string]bool{
+	"defs": true, "clipPath": true, "mask": true, "pattern": true, "symbol": true, "marker": true,
+	"metadata": true, "title": true, "desc": true, "style": true, "script": true,
+	"linearGradient": true, "radialGradient": true, "filter": true,
+}
+
+// ParseSVG 解析 SVG 文本，返回按文档顺序排列的所有填充图形以及 viewBox。
+// 支持 g 的嵌套 transform，path/rect/circle/ellipse/polygon/polyline 元素，
+// 以及 fill 属性与 style 中 fill 的继承；viewBox 原点被平移到 (0,0)
+func ParseSVG(svgStr string) ([]Shape, v2btypes.ViewBox, error) {
+	decoder := xml.NewDecoder(strings.NewReader(svgStr))
+	var (
+		shapes  []Shape
+		vb      v2btypes.ViewBox
+		rootSet bool
+		origin  matrix = identity
+		stack          = []elemState{{m: identity, display: true}}
+		skip    int
+	)
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return shapes, vb, fmt.Errorf("svg2json: %w", err)
+		}
+
+		switch se := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 || skippedElements[se.Name.Local] {
+				skip++
+				continue
+			}
+			attrs := make(map[string]string, len(se.Attr))
+			for _, attr := range se.Attr {
+				attrs[attr.Name.Local] = attr.Value
+			}
+			for k, v := range parseStyle(attrs["style"]) {
+				attrs[k] = v
+			}
+
+			parent := stack[len(stack)-1]
+			st := parent
+			if se.Name.Local == "svg" && !rootSet {
+				rootSet = true
+				vb = rootViewBox(attrs)
+				origin = matrix{a: 1, d: 1, e: -vb.X, f: -vb.Y}
+				vb.X, vb.Y = 0, 0
+				st.m = origin
+			}
+			if t, ok := attrs["transform"]; ok {
+				m, err := parseTransform(t)
+				if err != nil {
+					return shapes, vb, err
+				}
+				st.m = st.m.mul(m)
+			}
+			if f, ok := attrs["fill"]; ok && f != "inherit" {
+				st.fill = f
+			}
+			if attrs["display"] == "none" {
+				st.display = false
+			}
+			stack = append(stack, st)
+
+			if !st.display {
+				continue
+			}
+			d, ok := shapeData(se.Name.Local, attrs)
+			if !ok {
+				continue
+			}
+			path, err := pathdata.Parse(d)
+			if err != nil {
+				return shapes, vb, fmt.Errorf("svg2json: <%s>: %w", se.Name.Local, err)
+			}
+			if path.Empty() {
+				continue
+			}
+			shape := Shape{Path: path.Transform(st.m.apply)}
+			switch fill := strings.TrimSpace(st.fill); fill {
+			case "none", "transparent":
+				continue
+			case "":
+				shape.Fill = color.RGBA{A: 255}
+			default:
+				if c, ok := parseColor(fill); ok {
+					shape.Fill, shape.HasFill = c, true
+				} else {
+					shape.Fill = color.RGBA{A: 255}
+				}
+			}
+			shapes = append(shapes, shape)
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return shapes, vb, nil
+}
+
+// shapeData 将基本图形转换为等价的路径数据
+func shapeData(name string, attrs map[string]string) (string, bool) {
+	num := func(k string) float64 {
+		return parseLength(attrs[k])
+	}
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	switch name {
+	case "path":
+		d, ok := attrs["d"]
+		return d, ok
+	case "rect":
+		x, y, w, h := num("x"), num("y"), num("width"), num("height")
+		if w <= 0 || h <= 0 {
+			return "", false
+		}
+		rx, hasRx := attrs["rx"]
+		ry, hasRy := attrs["ry"]
+		rxv, ryv := parseLength(rx), parseLength(ry)
+		if hasRx && !hasRy {
+			ryv = rxv
+		} else if hasRy && !hasRx {
+			rxv = ryv
+		}
+		rxv, ryv = math.Min(rxv, w/2), math.Min(ryv, h/2)
+		if rxv <= 0 || ryv <= 0 {
+			return fmt.Sprintf("M%s %sH%sV%sH%sZ", f(x), f(y), f(x+w), f(y+h), f(x)), true
+		}
+		arc := func(ex, ey float64) string {
+			return fmt.Sprintf("A%s %s 0 0 1 %s %s", f(rxv), f(ryv), f(ex), f(ey))
+		}
+		return fmt.Sprintf("M%s %sH%s%sV%s%sH%s%sV%s%sZ",
+			f(x+rxv), f(y), f(x+w-rxv), arc(x+w, y+ryv),
+			f(y+h-ryv), arc(x+w-rxv, y+h),
+			f(x+rxv), arc(x, y+h-ryv),
+			f(y+ryv), arc(x+rxv, y)), true
+	case "circle", "ellipse":
+		cx, cy := num("cx"), num("cy")
+		rx, ry := num("rx"), num("ry")
+		if name == "circle" {
+			rx, ry = num("r"), num("r")
+		}
+		if rx <= 0 || ry <= 0 {
+			return "", false
+		}
+		return fmt.Sprintf("M%s %sA%s %s 0 1 1 %s %sA%s %s 0 1 1 %s %sZ",
+			f(cx-rx), f(cy), f(rx), f(ry), f(cx+rx), f(cy), f(rx), f(ry), f(cx-rx), f(cy)), true
+	case "polygon", "polyline":
+		pts := strings.TrimSpace(attrs["points"])
+		if pts == "" {
+			return "", false
+		}
+		d := "M" + pts
+		if name == "polygon" {
+			d += "Z"
+		}
+		return d, true
+	}
+	return "", false
+}
+
+// rootViewBox 读取根元素的 viewBox，缺省时使用 width/height
+func rootViewBox(attrs map[string]string) v2btypes.ViewBox {
+	if v, ok := attrs["viewBox"]; ok {
+		fields := strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' || r == '\t' || r == '\n' })
+		if len(fields) == 4 {
+			var n [4]float64
+			valid := true
+			for i, s := range fields {
+				f, err := strconv.ParseFloat(s, 64)
+				if err != nil {
+					valid = false
+					break
+				}
+				n[i] = f
+			}
+			if valid {
+				return v2btypes.ViewBox{X: n[0], Y: n[1], W: n[2], H: n[3]}
+			}
+		}
+	}
+	return v2btypes.ViewBox{W: parseLength(attrs["width"]), H: parseLength(attrs["height"])}
+}
+
+// parseLength 解析长度，忽略 px/pt 等单位
+func parseLength(s string) float64 {
+	s = strings.TrimSpace(s)
+	end := len(s)
+	for end > 0 && (s[end-1] < '0' || s[end-1] > '9') && s[end-1] != '.' {
+		end--
+	}
+	v, _ := strconv.ParseFloat(s[:end], 64)
+	return v
+}
+
+func parseStyle(style string) map[string]string {
+	out := map[string]string{}
+	for _, decl := range strings.Split(style, ";") {
+		k, v, ok := strings.Cut(decl, ":")
+		if ok {
+			out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return out
+}
+
+// parseTransform 解析 transform 属性中的变换列表，按从左到右的顺序组合
+func parseTransform(s string) (matrix, error) {
+	m := identity
+	rest := strings.TrimSpace(s)
+	for rest != "" {
+		open := strings.IndexByte(rest, '(')
+		closeIdx := strings.IndexByte(rest, ')')
+		if open < 0 || closeIdx < open {
+			return m, fmt.Errorf("svg2json: invalid transform %q", s)
+		}
+		name := strings.TrimSpace(rest[:open])
+		var args []float64
+		for _, f := range strings.FieldsFunc(rest[open+1:closeIdx], func(r rune) bool { return r == ' ' || r == ',' || r == '\t' || r == '\n' }) {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return m, fmt.Errorf("svg2json: invalid transform %q", s)
+			}
+			args = append(args, v)
+		}
+		arg := func(i int, def float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return def
+		}
+		var t matrix
+		switch name {
+		case "matrix":
+			t = matrix{arg(0, 1), arg(1, 0), arg(2, 0), arg(3, 1), arg(4, 0), arg(5, 0)}
+		case "translate":
+			t = matrix{a: 1, d: 1, e: arg(0, 0), f: arg(1, 0)}
+		case "scale":
+			sx := arg(0, 1)
+			t = matrix{a: sx, d: arg(1, sx)}
+		case "rotate":
+			r := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			rot := matrix{a: math.Cos(r), b: math.Sin(r), c: -math.Sin(r), d: math.Cos(r)}
+			t = matrix{a: 1, d: 1, e: cx, f: cy}.mul(rot).mul(matrix{a: 1, d: 1, e: -cx, f: -cy})
+		case "skewX":
+			t = matrix{a: 1, c: math.Tan(arg(0, 0) * math.Pi / 180), d: 1}
+		case "skewY":
+			t = matrix{a: 1, b: math.Tan(arg(0, 0) * math.Pi / 180), d: 1}
+		default:
+			return m, fmt.Errorf("svg2json: unknown transform %q", name)
+		}
+		m = m.mul(t)
+		rest = strings.TrimLeft(rest[closeIdx+1:], " ,\t\n")
+	}
+	return m, nil
+}
+
+var namedColors = map[string]color.RGBA{
+	"black":   {0, 0, 0, 255},
+	"white":   {255, 255, 255, 255},
+	"red":     {255, 0, 0, 255},
+	"green":   {0, 128, 0, 255},
+	"blue":    {0, 0, 255, 255},
+	"yellow":  {255, 255, 0, 255},
+	"cyan":    {0, 255, 255, 255},
+	"aqua":    {0, 255, 255, 255},
+	"magenta": {255, 0, 255, 255},
+	"fuchsia": {255, 0, 255, 255},
+	"gray":    {128, 128, 128, 255},
+	"grey":    {128, 128, 128, 255},
+	"silver":  {192, 192, 192, 255},
+	"maroon":  {128, 0, 0, 255},
+	"olive":   {128, 128, 0, 255},
+	"lime":    {0, 255, 0, 255},
+	"navy":    {0, 0, 128, 255},
+	"purple":  {128, 0, 128, 255},
+	"teal":    {0, 128, 128, 255},
+	"orange":  {255, 165, 0, 255},
+}
+
+// parseColor 解析 #rgb、#rrggbb、rgb() 以及常用颜色名
+func parseColor(s string) (color.RGBA, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[s]; ok {
+		return c, true
+	}
+	if strings.HasPrefix(s, "#") {
+		hex := s[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return color.RGBA{}, false
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return color.RGBA{}, false
+		}
+		return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, true
+	}
+	if strings.HasPrefix(s, "rgb(") && strings.HasSuffix(s, ")") {
+		parts := strings.Split(s[4:len(s)-1], ",")
+		if len(parts) != 3 {
+			return color.RGBA{}, false
+		}
+		var rgb [3]uint8
+		for i, p := range parts {
+			p = strings.TrimSpace(p)
+			var v float64
+			var err error
+			if strings.HasSuffix(p, "%") {
+				v, err = strconv.ParseFloat(strings.TrimSuffix(p, "%"), 64)
+				v = v * 255 / 100
+			} else {
+				v, err = strconv.ParseFloat(p, 64)
+			}
+			if err != nil {
+				return color.RGBA{}, false
+			}
+			rgb[i] = uint8(math.Max(0, math.Min(255, math.Round(v))))
+		}
+		return color.RGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 255}, true
+	}
+	return color.RGBA{}, false
+}
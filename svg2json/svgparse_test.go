@@ -0,0 +1,124 @@
+package svg2json
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"testing"
+	v2btypes "video2bas/type"
+)
+
+func parseShapes(t *testing.T, svg string) ([]Shape, v2btypes.ViewBox) {
+	t.Helper()
+	shapes, vb, err := ParseSVG(svg)
+	if err != nil {
+		t.Fatalf("parse: %v\n%s", err, svg)
+	}
+	return shapes, vb
+}
+
+func checkBBox(t *testing.T, name string, p v2btypes.Path, want v2btypes.BBox) {
+	t.Helper()
+	got := p.BBox()
+	const tol = 1e-3
+	if math.Abs(got.MinX-want.MinX) > tol || math.Abs(got.MinY-want.MinY) > tol ||
+		math.Abs(got.MaxX-want.MaxX) > tol || math.Abs(got.MaxY-want.MaxY) > tol {
+		t.Errorf("%s: bbox %+v, want %+v", name, got, want)
+	}
+}
+
+// 祖先元素的 transform 由外向内组合，同一属性中的变换从右向左作用，viewBox 原点移到 (0,0)
+func TestParseSVGNestedTransforms(t *testing.T) {
+	shapes, vb := parseShapes(t, `<svg viewBox="10 20 100 100">
+		<g transform="translate(10,0)">
+			<g transform="scale(2)">
+				<rect x="10" y="20" width="5" height="5"/>
+			</g>
+			<rect transform="translate(50 50) scale(2)" width="5" height="5"/>
+			<g transform="rotate(90 60 60)"><rect x="60" y="60" width="10" height="5"/></g>
+		</g>
+		<rect transform="matrix(1 0 0 1 30 40)" x="-10" y="-10" width="1" height="1"/>
+	</svg>`)
+	if vb != (v2btypes.ViewBox{W: 100, H: 100}) {
+		t.Errorf("viewBox %+v, want origin moved to 0,0", vb)
+	}
+	want := []v2btypes.BBox{
+		{MinX: 20, MinY: 20, MaxX: 30, MaxY: 30},
+		{MinX: 50, MinY: 30, MaxX: 60, MaxY: 40},
+		{MinX: 55, MinY: 40, MaxX: 60, MaxY: 50},
+		{MinX: 10, MinY: 10, MaxX: 11, MaxY: 11},
+	}
+	if len(shapes) != len(want) {
+		t.Fatalf("got %d shapes, want %d", len(shapes), len(want))
+	}
+	for i, s := range shapes {
+		checkBBox(t, fmt.Sprintf("shape %d", i), s.Path, want[i])
+	}
+}
+
+// fill 随元素嵌套继承，style 中的声明优先于属性；none、display:none 与 defs 中的图形不输出
+func TestParseSVGFillInheritance(t *testing.T) {
+	shapes, _ := parseShapes(t, `<svg viewBox="0 0 10 10">
+		<rect width="1" height="1"/>
+		<g fill="red" style="fill:#00ff00">
+			<rect width="1" height="1"/>
+			<g><rect width="1" height="1" fill="blue"/></g>
+			<rect width="1" height="1" style="fill: rgb(100%, 0%, 50%)"/>
+			<rect width="1" height="1" fill="inherit"/>
+			<rect width="1" height="1" fill="none"/>
+			<g style="display:none"><rect width="1" height="1"/></g>
+		</g>
+		<defs><rect id="r" width="1" height="1" fill="red"/></defs>
+		<g fill="none"><rect width="1" height="1"/></g>
+	</svg>`)
+	want := []struct {
+		fill    color.RGBA
+		hasFill bool
+	}{
+		{color.RGBA{A: 255}, false},
+		{color.RGBA{0, 255, 0, 255}, true},
+		{color.RGBA{0, 0, 255, 255}, true},
+		{color.RGBA{255, 0, 128, 255}, true},
+		{color.RGBA{0, 255, 0, 255}, true},
+	}
+	if len(shapes) != len(want) {
+		t.Fatalf("got %d shapes, want %d", len(shapes), len(want))
+	}
+	for i, s := range shapes {
+		if s.Fill != want[i].fill || s.HasFill != want[i].hasFill {
+			t.Errorf("shape %d: fill %v (explicit %v), want %v (explicit %v)", i, s.Fill, s.HasFill, want[i].fill, want[i].hasFill)
+		}
+	}
+}
+
+// 基本图形转换为等价路径
+func TestParseSVGShapes(t *testing.T) {
+	for _, tc := range []struct {
+		elem string
+		want v2btypes.BBox
+		subs int
+	}{
+		{`<rect x="1" y="2" width="30" height="20" rx="5"/>`, v2btypes.BBox{MinX: 1, MinY: 2, MaxX: 31, MaxY: 22}, 1},
+		{`<circle cx="50" cy="50" r="10"/>`, v2btypes.BBox{MinX: 40, MinY: 40, MaxX: 60, MaxY: 60}, 1},
+		{`<ellipse cx="50" cy="50" rx="20" ry="10"/>`, v2btypes.BBox{MinX: 30, MinY: 40, MaxX: 70, MaxY: 60}, 1},
+		{`<polygon points="0,0 10,0 10,10"/>`, v2btypes.BBox{MaxX: 10, MaxY: 10}, 1},
+		{`<polyline points="5 5, 15 5, 15 25"/>`, v2btypes.BBox{MinX: 5, MinY: 5, MaxX: 15, MaxY: 25}, 1},
+		{`<path d="M0 0h5v5zM10 10h5v5z"/>`, v2btypes.BBox{MaxX: 15, MaxY: 15}, 2},
+	} {
+		shapes, _ := parseShapes(t, `<svg viewBox="0 0 100 100">`+tc.elem+`</svg>`)
+		if len(shapes) != 1 {
+			t.Errorf("%s: got %d shapes, want 1", tc.elem, len(shapes))
+			continue
+		}
+		if n := len(shapes[0].Path.SubPaths); n != tc.subs {
+			t.Errorf("%s: got %d subpaths, want %d", tc.elem, n, tc.subs)
+		}
+		checkBBox(t, tc.elem, shapes[0].Path, tc.want)
+	}
+
+	// 尺寸为 0 的图形不输出
+	shapes, _ := parseShapes(t, `<svg viewBox="0 0 10 10"><rect width="0" height="5"/><circle r="0"/><polygon points=""/></svg>`)
+	if len(shapes) != 0 {
+		t.Errorf("got %d shapes from degenerate elements, want 0", len(shapes))
+	}
+}
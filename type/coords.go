@@ -0,0 +1,104 @@
+package v2btypes
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Fit 为源坐标空间与目标画面比例不一致时的适配方式
+type Fit string
+
+const (
+	FitLetterbox Fit = "letterbox" // 等比缩放到完全放入目标，居中留边
+	FitFill      Fit = "fill"      // 等比缩放到铺满目标，居中裁去超出部分
+	FitStretch   Fit = "stretch"   // 宽高分别缩放，铺满目标
+)
+
+// ParseFit 解析适配方式，空字符串为 letterbox
+func ParseFit(s string) (Fit, error) {
+	switch f := Fit(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return FitLetterbox, nil
+	case FitLetterbox, FitFill, FitStretch:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown fit %q (want letterbox, fill or stretch)", s)
+}
+
+// Transform 返回将 src 按适配方式映射到 dst 的坐标变换，任一方为空时不做变换
+func (f Fit) Transform(src, dst ViewBox) func(Point) Point {
+	if src.W <= 0 || src.H <= 0 || dst.W <= 0 || dst.H <= 0 {
+		return func(p Point) Point { return p }
+	}
+	sx, sy := dst.W/src.W, dst.H/src.H
+	switch f {
+	case FitFill:
+		sx = math.Max(sx, sy)
+		sy = sx
+	case FitStretch:
+	default:
+		sx = math.Min(sx, sy)
+		sy = sx
+	}
+	ox, oy := dst.X+(dst.W-src.W*sx)/2, dst.Y+(dst.H-src.H*sy)/2
+	return func(p Point) Point {
+		return Point{X: ox + (p.X-src.X)*sx, Y: oy + (p.Y-src.Y)*sy}
+	}
+}
+
+// PreserveAspectRatio 返回对应的 SVG preserveAspectRatio 属性值
+func (f Fit) PreserveAspectRatio() string {
+	switch f {
+	case FitFill:
+		return "xMidYMid slice"
+	case FitStretch:
+		return "none"
+	}
+	return "xMidYMid meet"
+}
+
+// Coords 描述输出的坐标空间：源坐标乘以 Scale 得到 viewBox 坐标；
+// 给出目标尺寸时改为按 Fit 映射到 0 0 W H，输出与源分辨率无关
+type Coords struct {
+	Scale float64 // 每个源单位对应的 viewBox 单位，0 视为 1
+	W, H  float64 // 目标 viewBox 尺寸，均大于 0 时生效，Scale 不再使用
+	Fit   Fit
+}
+
+// ParseSize 解析 "WxH" 形式的尺寸
+func ParseSize(s string) (w, h float64, err error) {
+	ws, hs, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "x")
+	if ok {
+		w, err = strconv.ParseFloat(ws, 64)
+		if err == nil {
+			h, err = strconv.ParseFloat(hs, 64)
+		}
+	}
+	if !ok || err != nil || !(w > 0) || !(h > 0) || math.IsInf(w, 0) || math.IsInf(h, 0) {
+		return 0, 0, fmt.Errorf("invalid size %q (want WxH)", s)
+	}
+	return w, h, nil
+}
+
+// Target 判断是否给出了目标 viewBox
+func (c Coords) Target() bool {
+	return c.W > 0 && c.H > 0
+}
+
+// Map 返回源 viewBox 在输出坐标空间中的 viewBox，以及源坐标到输出坐标的变换
+func (c Coords) Map(src ViewBox) (ViewBox, func(Point) Point) {
+	if c.Target() {
+		dst := ViewBox{W: c.W, H: c.H}
+		return dst, c.Fit.Transform(src, dst)
+	}
+	s := c.Scale
+	if s <= 0 {
+		s = 1
+	}
+	dst := ViewBox{W: src.W * s, H: src.H * s}
+	return dst, func(p Point) Point {
+		return Point{X: (p.X - src.X) * s, Y: (p.Y - src.Y) * s}
+	}
+}
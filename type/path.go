@@ -0,0 +1,235 @@
+package v2btypes
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Point 表示二维坐标，坐标系为 y 轴向下的 viewBox 空间
+type Point struct {
+	X, Y float64
+}
+
+// SegmentKind 表示路径段类型
+type SegmentKind int
+
+const (
+	SegLine  SegmentKind = iota // 直线，终点为 Pts[0]
+	SegCubic                    // 三次贝塞尔曲线，控制点为 Pts[0]、Pts[1]，终点为 Pts[2]
+)
+
+// Segment 表示子路径中的一段
+type Segment struct {
+	Kind SegmentKind
+	Pts  [3]Point
+}
+
+// End 返回该段的终点
+func (s Segment) End() Point {
+	if s.Kind == SegCubic {
+		return s.Pts[2]
+	}
+	return s.Pts[0]
+}
+
+// SubPath 表示从 Start 出发的一条连续子路径
+type SubPath struct {
+	Start    Point
+	Segments []Segment
+	Closed   bool
+}
+
+// Path 表示一个图层的完整几何图形
+type Path struct {
+	SubPaths []SubPath
+}
+
+// Empty 判断路径是否不含任何线段
+func (p Path) Empty() bool {
+	for _, sp := range p.SubPaths {
+		if len(sp.Segments) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// SameTopology 判断两条路径的子路径数、闭合与各段类型是否一一对应，即可逐点插值
+func (p Path) SameTopology(o Path) bool {
+	if len(p.SubPaths) != len(o.SubPaths) {
+		return false
+	}
+	for i, sp := range p.SubPaths {
+		osp := o.SubPaths[i]
+		if sp.Closed != osp.Closed || len(sp.Segments) != len(osp.Segments) {
+			return false
+		}
+		for j, seg := range sp.Segments {
+			if seg.Kind != osp.Segments[j].Kind {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Transform 返回对所有坐标应用 f 后的新路径
+func (p Path) Transform(f func(Point) Point) Path {
+	out := Path{SubPaths: make([]SubPath, len(p.SubPaths))}
+	for i, sp := range p.SubPaths {
+		nsp := SubPath{Start: f(sp.Start), Closed: sp.Closed, Segments: make([]Segment, len(sp.Segments))}
+		for j, seg := range sp.Segments {
+			nseg := Segment{Kind: seg.Kind}
+			n := 1
+			if seg.Kind == SegCubic {
+				n = 3
+			}
+			for k := 0; k < n; k++ {
+				nseg.Pts[k] = f(seg.Pts[k])
+			}
+			nsp.Segments[j] = nseg
+		}
+		out.SubPaths[i] = nsp
+	}
+	return out
+}
+
+// BBox 表示轴对齐包围盒
+type BBox struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+// Empty 判断包围盒是否为空
+func (b BBox) Empty() bool {
+	return b.MinX > b.MaxX || b.MinY > b.MaxY
+}
+
+func (b *BBox) add(p Point) {
+	b.MinX = math.Min(b.MinX, p.X)
+	b.MinY = math.Min(b.MinY, p.Y)
+	b.MaxX = math.Max(b.MaxX, p.X)
+	b.MaxY = math.Max(b.MaxY, p.Y)
+}
+
+// BBox 计算路径的精确包围盒（含贝塞尔曲线极值点），空路径返回空盒
+func (p Path) BBox() BBox {
+	b := BBox{MinX: math.Inf(1), MinY: math.Inf(1), MaxX: math.Inf(-1), MaxY: math.Inf(-1)}
+	for _, sp := range p.SubPaths {
+		if len(sp.Segments) == 0 {
+			continue
+		}
+		cur := sp.Start
+		b.add(cur)
+		for _, seg := range sp.Segments {
+			if seg.Kind == SegCubic {
+				for _, t := range cubicExtrema(cur.X, seg.Pts[0].X, seg.Pts[1].X, seg.Pts[2].X) {
+					b.add(cubicAt(cur, seg.Pts[0], seg.Pts[1], seg.Pts[2], t))
+				}
+				for _, t := range cubicExtrema(cur.Y, seg.Pts[0].Y, seg.Pts[1].Y, seg.Pts[2].Y) {
+					b.add(cubicAt(cur, seg.Pts[0], seg.Pts[1], seg.Pts[2], t))
+				}
+			}
+			cur = seg.End()
+			b.add(cur)
+		}
+	}
+	return b
+}
+
+func cubicAt(p0, p1, p2, p3 Point, t float64) Point {
+	mt := 1 - t
+	a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+	return Point{
+		X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+		Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+	}
+}
+
+// cubicExtrema 返回一维三次贝塞尔在 (0,1) 内导数为零的参数
+func cubicExtrema(p0, p1, p2, p3 float64) []float64 {
+	a := -p0 + 3*p1 - 3*p2 + p3
+	b := 2 * (p0 - 2*p1 + p2)
+	c := p1 - p0
+	var ts []float64
+	if math.Abs(a) < 1e-12 {
+		if math.Abs(b) > 1e-12 {
+			ts = append(ts, -c/b)
+		}
+	} else {
+		disc := b*b - 4*a*c
+		if disc >= 0 {
+			sq := math.Sqrt(disc)
+			ts = append(ts, (-b+sq)/(2*a), (-b-sq)/(2*a))
+		}
+	}
+	out := ts[:0]
+	for _, t := range ts {
+		if t > 0 && t < 1 {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// ViewBox 表示 SVG 的 viewBox
+type ViewBox struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	W float64 `json:"w"`
+	H float64 `json:"h"`
+}
+
+// String 返回 "minX minY width height" 形式
+func (v ViewBox) String() string {
+	return formatCoord(v.X, -1) + " " + formatCoord(v.Y, -1) + " " + formatCoord(v.W, -1) + " " + formatCoord(v.H, -1)
+}
+
+// SVG 以绝对坐标命令输出路径数据，坐标保留 prec 位小数（prec < 0 表示不限制）
+func (p Path) SVG(prec int) string {
+	var sb strings.Builder
+	writePt := func(pt Point) {
+		sb.WriteString(formatCoord(pt.X, prec))
+		sb.WriteByte(' ')
+		sb.WriteString(formatCoord(pt.Y, prec))
+	}
+	for _, sp := range p.SubPaths {
+		if len(sp.Segments) == 0 {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString("M ")
+		writePt(sp.Start)
+		for _, seg := range sp.Segments {
+			switch seg.Kind {
+			case SegLine:
+				sb.WriteString(" L ")
+				writePt(seg.Pts[0])
+			case SegCubic:
+				sb.WriteString(" C ")
+				writePt(seg.Pts[0])
+				sb.WriteByte(' ')
+				writePt(seg.Pts[1])
+				sb.WriteByte(' ')
+				writePt(seg.Pts[2])
+			}
+		}
+		if sp.Closed {
+			sb.WriteString(" Z")
+		}
+	}
+	return sb.String()
+}
+
+func formatCoord(v float64, prec int) string {
+	if prec >= 0 {
+		scale := math.Pow10(prec)
+		v = math.Round(v*scale) / scale
+	}
+	if v == 0 {
+		v = 0 // 去掉 -0
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
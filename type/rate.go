@@ -0,0 +1,103 @@
+package v2btypes
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Rate 表示精确的有理数帧率 Num/Den 帧每秒，如 NTSC 的 30000/1001
+type Rate struct {
+	Num, Den int64
+}
+
+// ParseRate 解析 "30"、"12.5"、"30000/1001" 形式的帧率；
+// 23.976、29.97、59.94 等 NTSC 近似值解析为对应的 N*1000/1001
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if num, den, ok := strings.Cut(s, "/"); ok {
+		n, err1 := strconv.ParseInt(strings.TrimSpace(num), 10, 64)
+		d, err2 := strconv.ParseInt(strings.TrimSpace(den), 10, 64)
+		if err1 != nil || err2 != nil || n <= 0 || d <= 0 {
+			return Rate{}, fmt.Errorf("invalid frame rate %q", s)
+		}
+		return Rate{Num: n, Den: d}.reduce(), nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f <= 0 || math.IsInf(f, 0) {
+		return Rate{}, fmt.Errorf("invalid frame rate %q", s)
+	}
+	if f != math.Trunc(f) {
+		if n := math.Round(f * 1001 / 1000); math.Abs(n*1000/1001-f) < 0.005 {
+			return Rate{Num: int64(n) * 1000, Den: 1001}, nil
+		}
+	}
+	// 按十进制小数精确换算
+	den := int64(1)
+	if _, frac, ok := strings.Cut(s, "."); ok {
+		for range min(len(frac), 9) {
+			den *= 10
+		}
+	}
+	return Rate{Num: int64(math.Round(f * float64(den))), Den: den}.reduce(), nil
+}
+
+// RateFromFloat 将浮点帧率转换为 Rate，规则同 ParseRate
+func RateFromFloat(f float64) Rate {
+	r, err := ParseRate(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		return Rate{Num: 1, Den: 1}
+	}
+	return r
+}
+
+// NewRate 返回约分后的 num/den
+func NewRate(num, den int64) Rate {
+	return Rate{Num: num, Den: den}.reduce()
+}
+
+// Div 返回帧率的 1/k，即每 k 帧取一帧
+func (r Rate) Div(k int64) Rate {
+	return NewRate(r.Num, r.Den*k)
+}
+
+func (r Rate) reduce() Rate {
+	a, b := r.Num, r.Den
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return Rate{Num: r.Num / a, Den: r.Den / a}
+}
+
+// Valid 判断帧率是否为正
+func (r Rate) Valid() bool {
+	return r.Num > 0 && r.Den > 0
+}
+
+// Float 返回浮点帧率
+func (r Rate) Float() float64 {
+	return float64(r.Num) / float64(r.Den)
+}
+
+// String 返回 "30" 或 "30000/1001" 形式，可直接传给 ffmpeg
+func (r Rate) String() string {
+	if r.Den == 1 {
+		return strconv.FormatInt(r.Num, 10)
+	}
+	return strconv.FormatInt(r.Num, 10) + "/" + strconv.FormatInt(r.Den, 10)
+}
+
+// FrameStart 返回第 i 帧的开始时间（毫秒），为理想时间四舍五入，误差不超过 0.5ms 且不累积
+func (r Rate) FrameStart(i int) int64 {
+	n := int64(i) * 1000 * r.Den
+	if n < 0 {
+		return -((-2*n + r.Num) / (2 * r.Num))
+	}
+	return (2*n + r.Num) / (2 * r.Num)
+}
+
+// FrameEnd 返回第 i 帧的结束时间，即下一帧的开始时间
+func (r Rate) FrameEnd(i int) int64 {
+	return r.FrameStart(i + 1)
+}
@@ -0,0 +1,51 @@
+package v2btypes
+
+import "testing"
+
+// 常见帧率下各帧时间与理想时间轴的误差不超过 0.5ms，且相邻帧首尾相接
+func TestFrameTimeline(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Rate
+	}{
+		{"24", Rate{Num: 24, Den: 1}},
+		{"25", Rate{Num: 25, Den: 1}},
+		{"29.97", Rate{Num: 30000, Den: 1001}},
+		{"30000/1001", Rate{Num: 30000, Den: 1001}},
+		{"30", Rate{Num: 30, Den: 1}},
+		{"60", Rate{Num: 60, Den: 1}},
+		{"23.976", Rate{Num: 24000, Den: 1001}},
+		{"12.5", Rate{Num: 25, Den: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			r, err := ParseRate(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r != tt.want {
+				t.Fatalf("ParseRate(%q) = %v, want %v", tt.in, r, tt.want)
+			}
+			// 覆盖 10 分钟以上的帧
+			frames := int(10*60*r.Num/r.Den) + 1
+			for i := 0; i <= frames; i++ {
+				start, end := r.FrameStart(i), r.FrameEnd(i)
+				// |start - i*1000*Den/Num| <= 0.5，两边同乘 2*Num 以精确比较
+				if d := 2*start*r.Num - 2*int64(i)*1000*r.Den; d > r.Num || d < -r.Num {
+					t.Fatalf("frame %d starts at %dms, ideal %.3fms", i, start, float64(i)*1000*float64(r.Den)/float64(r.Num))
+				}
+				if end != r.FrameStart(i+1) || end <= start {
+					t.Fatalf("frame %d spans %d-%dms, next starts at %dms", i, start, end, r.FrameStart(i+1))
+				}
+			}
+		})
+	}
+}
+
+func TestParseRateInvalid(t *testing.T) {
+	for _, in := range []string{"", "0", "-30", "abc", "30/0", "0/1001", "inf"} {
+		if r, err := ParseRate(in); err == nil {
+			t.Errorf("ParseRate(%q) = %v, want error", in, r)
+		}
+	}
+}
@@ -1,27 +1,85 @@
 package v2btypes
 
 import (
+	"encoding/json"
+	"fmt"
 	"image"
 	"image/color"
+	"strconv"
+	"strings"
 )
 
-// LayerSVG 表示单个颜色图层的 SVG
+// LayerSVG 表示单个颜色图层的矢量结果
 type LayerSVG struct {
-	ColorIndex int
+	ColorIndex int // 在调色板中的序号，图层按其从下到上排列
 	Color      color.RGBA
-	SVGData    string
+	Path       Path   // 描摹得到的几何数据
+	SVGData    string // SVG 文本，仅在显式要求或外部输入时存在
 }
 
-// FrameSVG 表示一帧所有颜色层的 SVG
+// FrameSVG 表示一帧所有颜色层的矢量结果
 type FrameSVG struct {
 	FrameIndex int
+	ViewBox    ViewBox
 	Layers     []LayerSVG
+	Skipped    int // 之前各阶段累计跳过的空图层数
 }
 
-// FrameData 封装输出的数据结构
+// FrameDataVersion 为当前 FrameData 交换格式的版本号
+const FrameDataVersion = 1
+
+// Layer 表示一帧中的一个颜色图层
+type Layer struct {
+	Color    string  `json:"color"`           // 填充色 HEX（如 "FF0000"）
+	PathData string  `json:"pathdata"`        // SVG 路径数据，坐标为 y 轴向下的 viewBox 空间
+	Z        int     `json:"z"`               // 叠放顺序，越大越靠上，不要求连续；相邻帧中 Z 相同的图层视为同一槽位。描摹结果为调色板序号，导入的 SVG 为图形序号
+	Alpha    float64 `json:"alpha"`           // 不透明度 0~1，缺省为 1
+	Start    int64   `json:"start,omitempty"` // 显示开始时间（毫秒），与 End 均为 0 时按帧率推算
+	End      int64   `json:"end,omitempty"`   // 显示结束时间（毫秒）
+	Path     *Path   `json:"-"`               // 几何数据，存在时优先于 PathData
+}
+
+// UnmarshalJSON 在缺省 alpha 时补为 1
+func (l *Layer) UnmarshalJSON(b []byte) error {
+	type plain Layer
+	p := plain{Alpha: 1}
+	if err := json.Unmarshal(b, &p); err != nil {
+		return err
+	}
+	*l = Layer(p)
+	return nil
+}
+
+// HasTiming 判断图层是否自带显示时间
+func (l Layer) HasTiming() bool {
+	return l.End > l.Start
+}
+
+// FrameData 封装输出的数据结构，可逐行序列化为 JSONL 在工具之间交换
 type FrameData struct {
-	FrameIndex int                 `json:"frameIndex"`
-	Data       []map[string]string `json:"data"`
+	Version    int     `json:"version"`
+	FrameIndex int     `json:"frameIndex"`
+	ViewBox    ViewBox `json:"viewBox"`
+	Layers     []Layer `json:"layers"`
+	Skipped    int     `json:"skipped,omitempty"` // 之前各阶段累计跳过的空图层数
+}
+
+// HexColor 将颜色格式化为 "RRGGBB"
+func HexColor(c color.RGBA) string {
+	return fmt.Sprintf("%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// ParseHexColor 解析 "RRGGBB"（可带 # 前缀）
+func ParseHexColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
 }
 
 // Frame 表示一帧图像
@@ -32,14 +90,18 @@ type Frame struct {
 
 // ColorLayer 表示某一帧中某个颜色的分割图层
 type ColorLayer struct {
-	Color color.RGBA  // 颜色 HEX（如 "FF0000"）
-	Mask  *image.Gray // 黑白掩码图：黑=该颜色，白=其他
+	Color        color.RGBA  // 颜色 HEX（如 "FF0000"）
+	Mask         *image.Gray // 黑白掩码图：黑=该颜色，白=其他
+	PaletteIndex int         // 在调色板中的序号
+	Area         int         // 该颜色的像素数
 }
 
 // FrameLayers 表示某一帧的分层结果
 type FrameLayers struct {
-	Index  int
-	Layers []ColorLayer
+	Index         int
+	Width, Height int // 帧尺寸（像素）
+	Layers        []ColorLayer
+	Skipped       int // 因面积过小被跳过的图层数
 }
 
 type Pixel struct {
@@ -5,71 +5,69 @@ import (
 	"image/png"
 	"io"
 	"log"
-	"os"
-	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+	"video2bas/bas2xml"
+	"video2bas/basgen"
 	"video2bas/color2svg"
+	"video2bas/json2ass"
 	"video2bas/json2bas"
+	"video2bas/pathdata"
 	"video2bas/svg2json"
 	v2btypes "video2bas/type"
 	"video2bas/video2color"
 
 	"runtime"
-
-	"github.com/rustyoz/svg"
 )
 
-func generateBasToFile(ctx context.Context, videoPath string, fps, maxWidth, colorCount, maxFileSize int, outputPath string, parallel int) {
-	basLines := generateBas(ctx, videoPath, fps, maxWidth, colorCount, parallel)
+// pipelineOptions 汇总一次转换的全部参数
+type pipelineOptions struct {
+	VideoPath   string
+	FPS         v2btypes.Rate
+	MaxWidth    int
+	ColorCount  int
+	MaxFileSize int
+	OutputPath  string
+	Parallel    int
+	Split       video2color.SplitOptions
+	Trace       color2svg.TraceOptions
+	Path        pathdata.Options
+	Formats     []string        // 输出格式，见 emit.Formats
+	FramesOut   string          // 非空时将中间 FrameData 以 JSONL 写出
+	FramesIn    string          // 非空时从 JSONL 读取 FrameData，不处理视频
+	Strategy    string          // json2bas 输出策略：frame 或 pool
+	KeepBlack   bool            // 保留黑色图层；视频转换时黑色视为背景跳过
+	Delta       bool            // 与上一帧相同的图层只延长显示
+	Tween       bool            // 与上一帧拓扑相同的图层以补间过渡
+	Coords      v2btypes.Coords // 输出坐标空间，视频转换时由 Trace.Coords 使用
+	Player      float64         // 播放器画面的宽高比，决定 BAS 对象的尺寸与位置
+	Style       *json2bas.Style
+	IDs         *basgen.IDAllocator // 整个运行共用，保证各分块的标识符不重复
+	IDMap       string              // 非空时写出标识符映射文件
+	ASSOut      string              // 非空时同时输出 ASS 字幕
+	ASS         json2ass.Options
+	SVGOut      string  // 非空时同时输出 SMIL 动画 SVG
+	HTMLOut     string  // 非空时同时输出叠加在源视频上的 HTML 预览页
+	LottieOut   string  // 非空时同时输出 Lottie JSON
+	StartTime   float64 // 动画在视频中的起始时间（毫秒），即 .bas.txt 分块的发送时间；XML 中各分块的发送时间为其加上分块第一帧的时间
+	XMLOut      string  // 非空时将各分块写为哔哩哔哩弹幕 XML
+	XML         bas2xml.Options
+}
 
-	//检查outputPath的目录是否存在，不存在则创建
-	if strings.Contains(outputPath, "/") {
-		dir := strings.TrimRight(outputPath, "/")
-		if dir != "" {
-			dir = dir[:strings.LastIndex(dir, "/")]
-			if dir != "" {
-				err := os.MkdirAll(dir, os.ModePerm)
-				log.Println("Output directory:", dir)
-				if err != nil {
-					log.Fatal(err)
-				}
-			}
-		}
-	}
+func generateBasToFile(ctx context.Context, opts pipelineOptions) {
+	data := generateFrameData(ctx, opts)
 
-	fileId := 0
-	currentFileSize := 0
-	var currentFile *os.File
-	var err error
-	for _, line := range basLines {
-		lineSize := len(line) + 1 // +1 for newline
-		if currentFile == nil || currentFileSize+lineSize > maxFileSize {
-			if currentFile != nil {
-				currentFile.Close()
-			}
-			currentFile, err = os.Create(outputPath + "_" + strconv.Itoa(fileId) + ".bas.txt")
-			if err != nil {
-				log.Fatal(err)
-			}
-			fileId++
-			currentFileSize = 0
-		}
-		_, err := currentFile.WriteString(line + "\n")
-		if err != nil {
-			log.Fatal(err)
-		}
-		currentFileSize += lineSize
-	}
-	if currentFile != nil {
-		currentFile.Close()
-	}
-	log.Println("Output Bas files count:", fileId)
+	log.Println("Generating outputs...")
+	out := newOutputs(opts)
+	out.Frames(data)
+	out.Close()
 }
 
-func generateBas(ctx context.Context, videoPath string, fps, maxWidth, colorCount int, parallel int) []string {
+func generateFrameData(ctx context.Context, opts pipelineOptions) []v2btypes.FrameData {
+	fps, parallel := opts.FPS, opts.Parallel
 	log.Println("Extracting frames from video...")
-	frames, err := video2color.ExtractFrames(ctx, videoPath, fps, maxWidth)
+	frames, err := video2color.ExtractFrames(ctx, opts.VideoPath, fps, opts.MaxWidth)
 	if err != nil {
 		log.Println("Error extracting frames:")
 		log.Fatal(err)
@@ -79,8 +77,7 @@ func generateBas(ctx context.Context, videoPath string, fps, maxWidth, colorCoun
 	log.Println("Splitting frames into color layers...")
 	// 进度监控：分层
 	frameLayers := make([]v2btypes.FrameLayers, len(frames))
-	var splitDoneCount int
-	splitDoneCh := make(chan int, len(frames))
+	var splitDoneCount atomic.Int64
 	stopSplitProgress := make(chan struct{})
 	go func() {
 		ticker := time.NewTicker(10 * time.Second)
@@ -88,15 +85,14 @@ func generateBas(ctx context.Context, videoPath string, fps, maxWidth, colorCoun
 		for {
 			select {
 			case <-ticker.C:
-				log.Printf("[Progress] Splitting: %d/%d", splitDoneCount, len(frames))
+				log.Printf("[Progress] Splitting: %d/%d", splitDoneCount.Load(), len(frames))
 			case <-stopSplitProgress:
 				return
 			}
 		}
 	}()
-	frameLayers, err = video2color.SplitAllFramesAutoWithProgress(frames, colorCount, parallel, func() {
-		splitDoneCount++
-		splitDoneCh <- 1
+	frameLayers, err = video2color.SplitAllFramesAutoWithOptions(frames, opts.ColorCount, parallel, opts.Split, func() {
+		splitDoneCount.Add(1)
 	})
 	close(stopSplitProgress)
 	if err != nil {
@@ -107,7 +103,8 @@ func generateBas(ctx context.Context, videoPath string, fps, maxWidth, colorCoun
 
 	// 进度监控：SVG
 	svgLayers := make([]v2btypes.FrameSVG, len(frameLayers))
-	var svgDoneCount int
+	// 进度回调在描摹的工作协程中调用，计数需原子操作
+	var svgDoneCount atomic.Int64
 	stopSvgProgress := make(chan struct{})
 	go func() {
 		ticker := time.NewTicker(10 * time.Second)
@@ -115,14 +112,14 @@ func generateBas(ctx context.Context, videoPath string, fps, maxWidth, colorCoun
 		for {
 			select {
 			case <-ticker.C:
-				log.Printf("[Progress] Converting SVG: %d/%d", svgDoneCount, len(frameLayers))
+				log.Printf("[Progress] Converting SVG: %d/%d", svgDoneCount.Load(), len(frameLayers))
 			case <-stopSvgProgress:
 				return
 			}
 		}
 	}()
-	svgLayers, err = color2svg.ConvertToSVGWithProgress(frameLayers, func() {
-		svgDoneCount++
+	svgLayers, err = color2svg.ConvertToSVGParallel(ctx, frameLayers, opts.Trace, parallel, func() {
+		svgDoneCount.Add(1)
 	})
 	close(stopSvgProgress)
 	if err != nil {
@@ -132,7 +129,7 @@ func generateBas(ctx context.Context, videoPath string, fps, maxWidth, colorCoun
 
 	// 进度监控：SVG2JSON
 	data := make([]v2btypes.FrameData, len(svgLayers))
-	var jsonDoneCount int
+	var jsonDoneCount atomic.Int64
 	stopJsonProgress := make(chan struct{})
 	go func() {
 		ticker := time.NewTicker(10 * time.Second)
@@ -140,89 +137,34 @@ func generateBas(ctx context.Context, videoPath string, fps, maxWidth, colorCoun
 		for {
 			select {
 			case <-ticker.C:
-				log.Printf("[Progress] Transform svg data: %d/%d", jsonDoneCount, len(svgLayers))
+				log.Printf("[Progress] Transform svg data: %d/%d", jsonDoneCount.Load(), len(svgLayers))
 			case <-stopJsonProgress:
 				return
 			}
 		}
 	}()
 	data = svg2json.ParseAllFrameWithParallelProgress(svgLayers, parallel, func() {
-		jsonDoneCount++
+		jsonDoneCount.Add(1)
 	})
 	close(stopJsonProgress)
-
-	svgData := svgLayers[0].Layers[0].SVGData
-	parsed, err := svg.ParseSvg(svgData, "example", 1.0)
-	if err != nil {
-		log.Fatal(err)
-	}
-	box := parsed.ViewBox
-	//从box读取4个float64
-	split := strings.Split(box, " ")
-	floats := make([]float64, 4)
-	for idx, s := range split {
-		f, err := strconv.ParseFloat(s, 64)
-		if err != nil {
-			log.Fatal(err)
-		}
-		floats[idx] = f
-	}
-	width := int(floats[2] * 10)
-	height := int(floats[3] * 10)
-
-	log.Println("Generating BAS code...")
-	return json2bas.GenerateAllBasTextWithParallel(data, width, height, float64(fps), 0, parallel)
+	return data
 }
 
 // 串行处理，最大程度减少内存占用，直接写入文件
-func generateBasSerial(ctx context.Context, videoPath string, fps, maxWidth, colorCount int, maxFileSize int, outputPath string) {
+func generateBasSerial(ctx context.Context, opts pipelineOptions) {
+	fps := opts.FPS
 	log.Println("Extracting frames from video (streaming)...")
 
-	reader, closer, err := video2color.ExtractFramesStream(ctx, videoPath, fps, maxWidth)
+	reader, closer, err := video2color.ExtractFramesStream(ctx, opts.VideoPath, fps, opts.MaxWidth)
 	if err != nil {
 		log.Println("Error extracting frames:")
 		log.Fatal(err)
 	}
 	defer closer.Close()
 
-	// 检查outputPath的目录是否存在，不存在则创建
-	if strings.Contains(outputPath, "/") {
-		dir := strings.TrimRight(outputPath, "/")
-		if dir != "" {
-			dir = dir[:strings.LastIndex(dir, "/")]
-			if dir != "" {
-				err := os.MkdirAll(dir, os.ModePerm)
-				log.Println("Output directory:", dir)
-				if err != nil {
-					log.Fatal(err)
-				}
-			}
-		}
-	}
-
-	fileId := 0
-	currentFileSize := 0
-	var currentFile *os.File
-
-	openNewFile := func() {
-		if currentFile != nil {
-			currentFile.Close()
-		}
-		var err error
-		currentFile, err = os.Create(outputPath + "_" + strconv.Itoa(fileId) + ".bas.txt")
-		if err != nil {
-			log.Fatal(err)
-		}
-		fileId++
-		currentFileSize = 0
-	}
-	openNewFile()
-
-	// 获取宽高
-	var width, height int
-	var boxParsed bool
+	out := newOutputs(opts)
 
-	var splitDoneCount, svgDoneCount, jsonDoneCount, total int
+	var splitDoneCount, svgDoneCount, jsonDoneCount, total atomic.Int64
 
 	// 进度打印
 	stopProgress := make(chan struct{})
@@ -232,7 +174,7 @@ func generateBasSerial(ctx context.Context, videoPath string, fps, maxWidth, col
 		for {
 			select {
 			case <-ticker.C:
-				log.Printf("[Serial Progress] Split: %d, SVG: %d, JSON: %d, Total: %d", splitDoneCount, svgDoneCount, jsonDoneCount, total)
+				log.Printf("[Serial Progress] Split: %d, SVG: %d, JSON: %d, Total: %d", splitDoneCount.Load(), svgDoneCount.Load(), jsonDoneCount.Load(), total.Load())
 			case <-stopProgress:
 				return
 			}
@@ -249,60 +191,29 @@ func generateBasSerial(ctx context.Context, videoPath string, fps, maxWidth, col
 			log.Fatalf("decode frame %d failed: %v", frameIndex, err)
 		}
 		frame := v2btypes.Frame{Index: frameIndex, Image: img}
-		total++
+		total.Add(1)
 
 		// 分层
-		frameLayers, err := video2color.SplitColorsAuto(frame, colorCount)
+		frameLayers, err := video2color.SplitColorsAutoWithOptions(frame, opts.ColorCount, opts.Split)
 		if err != nil {
 			log.Fatalf("SplitColorsAuto error at frame %d: %v", frame.Index, err)
 		}
-		splitDoneCount++
+		splitDoneCount.Add(1)
 
 		// 转SVG
-		svgLayers, err := color2svg.ConvertToSVG([]v2btypes.FrameLayers{frameLayers})
+		svgLayers, err := color2svg.ConvertToSVGWithOptions([]v2btypes.FrameLayers{frameLayers}, opts.Trace, nil)
 		if err != nil {
 			log.Fatalf("ConvertToSVG error at frame %d: %v", frame.Index, err)
 		}
-		svgDoneCount++
-
-		// 只需一次获取宽高
-		if !boxParsed && len(svgLayers) > 0 && len(svgLayers[0].Layers) > 0 {
-			svgData := svgLayers[0].Layers[0].SVGData
-			parsed, err := svg.ParseSvg(svgData, "example", 1.0)
-			if err != nil {
-				log.Fatal(err)
-			}
-			box := parsed.ViewBox
-			split := strings.Split(box, " ")
-			floats := make([]float64, 4)
-			for idx, s := range split {
-				f, err := strconv.ParseFloat(s, 64)
-				if err != nil {
-					log.Fatal(err)
-				}
-				floats[idx] = f
-			}
-			width = int(floats[2] * 10)
-			height = int(floats[3] * 10)
-			boxParsed = true
-		}
+		svgDoneCount.Add(1)
 
 		// SVG转JSON
 		data := svg2json.ParseAllFrame(svgLayers)
-		jsonDoneCount++
+		jsonDoneCount.Add(1)
 
-		// 生成BAS
-		bas := json2bas.GenerateAllBasText(data, width, height, float64(fps), 0)
-		for _, line := range bas {
-			lineSize := len(line) + 1 // +1 for newline
-			if currentFileSize+lineSize > maxFileSize {
-				openNewFile()
-			}
-			_, err := currentFile.WriteString(line + "\n")
-			if err != nil {
-				log.Fatal(err)
-			}
-			currentFileSize += lineSize
+		// 生成各格式输出，以第一帧的宽高为准
+		for _, fd := range data {
+			out.Frame(fd)
 		}
 
 		// 主动释放内存
@@ -314,10 +225,7 @@ func generateBasSerial(ctx context.Context, videoPath string, fps, maxWidth, col
 		frameIndex++
 	}
 
-	if currentFile != nil {
-		currentFile.Close()
-	}
 	close(stopProgress)
-	log.Println("Output Bas files count:", fileId)
-	log.Println("Generating BAS code done.")
+	out.Close()
+	log.Println("Generating outputs done.")
 }
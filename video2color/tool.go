@@ -22,6 +22,27 @@ type VideoProbe struct {
 	} `json:"streams"`
 }
 
+// ProbeDuration 返回视频时长（秒）
+func ProbeDuration(videoPath string) (float64, error) {
+	probeStr, err := ffmpeg.Probe(videoPath)
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe error: %w", err)
+	}
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal([]byte(probeStr), &probe); err != nil {
+		return 0, fmt.Errorf("json unmarshal error: %w", err)
+	}
+	d, err := strconv.ParseFloat(probe.Format.Duration, 64)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("cannot determine duration of %s", videoPath)
+	}
+	return d, nil
+}
+
 // getTotalFrames 从 probe 数据解析总帧数
 func getTotalFrames(videoPath string) (int, error) {
 	probeStr, err := ffmpeg.Probe(videoPath)
@@ -11,7 +11,6 @@ import (
 	"io"
 	"math"
 	"os"
-	"strconv"
 	"strings"
 	"sync"
 	v2btypes "video2bas/type"
@@ -19,19 +18,28 @@ import (
 	ffmpeg "github.com/u2takey/ffmpeg-go"
 )
 
-func ExtractFrames(ctx context.Context, videoPath string, fps, maxWidth int) ([]v2btypes.Frame, error) {
-	if fps <= 0 {
-		fps = 1
+func ExtractFrames(ctx context.Context, videoPath string, fps v2btypes.Rate, maxWidth int) ([]v2btypes.Frame, error) {
+	return extractFrames(ffmpeg.Input(videoPath), fps, maxWidth)
+}
+
+// ExtractClip 提取从 start 秒开始、时长 duration 秒的片段中的帧
+func ExtractClip(ctx context.Context, videoPath string, fps v2btypes.Rate, maxWidth int, start, duration float64) ([]v2btypes.Frame, error) {
+	return extractFrames(ffmpeg.Input(videoPath, ffmpeg.KwArgs{"ss": start, "t": duration}), fps, maxWidth)
+}
+
+func extractFrames(input *ffmpeg.Stream, fps v2btypes.Rate, maxWidth int) ([]v2btypes.Frame, error) {
+	if !fps.Valid() {
+		fps = v2btypes.Rate{Num: 1, Den: 1}
 	}
 	r, w := io.Pipe()
 
 	go func() {
 		defer w.Close()
-		cmd := ffmpeg.Input(videoPath).
+		cmd := input.
 			Output("pipe:1", ffmpeg.KwArgs{
 				"format":   "image2pipe",
 				"vcodec":   "png",
-				"r":        strconv.Itoa(fps),
+				"r":        fps.String(),
 				"vf":       fmt.Sprintf("scale=%d:-1", maxWidth),
 				"loglevel": "error",
 			}).
@@ -72,9 +80,9 @@ func ExtractFrames(ctx context.Context, videoPath string, fps, maxWidth int) ([]
 }
 
 // ExtractFramesStream 返回 bufio.Reader，调用方可流式读取帧
-func ExtractFramesStream(ctx context.Context, videoPath string, fps, maxWidth int) (*bufio.Reader, io.Closer, error) {
-	if fps <= 0 {
-		fps = 1
+func ExtractFramesStream(ctx context.Context, videoPath string, fps v2btypes.Rate, maxWidth int) (*bufio.Reader, io.Closer, error) {
+	if !fps.Valid() {
+		fps = v2btypes.Rate{Num: 1, Den: 1}
 	}
 
 	r, w := io.Pipe()
@@ -85,7 +93,7 @@ func ExtractFramesStream(ctx context.Context, videoPath string, fps, maxWidth in
 			Output("pipe:1", ffmpeg.KwArgs{
 				"format":   "image2pipe",
 				"vcodec":   "png",
-				"r":        strconv.Itoa(fps),
+				"r":        fps.String(),
 				"vf":       fmt.Sprintf("scale=%d:-1", maxWidth),
 				"loglevel": "error",
 			}).
@@ -102,13 +110,36 @@ func ExtractFramesStream(ctx context.Context, videoPath string, fps, maxWidth in
 	return bufio.NewReader(r), r, nil
 }
 
+// SplitOptions 控制颜色分层
+type SplitOptions struct {
+	MinArea int // 像素数低于该值的图层直接丢弃，不再分配掩码
+}
+
+// DefaultSplitOptions 返回默认参数：只丢弃完全为空的图层
+func DefaultSplitOptions() SplitOptions {
+	return SplitOptions{MinArea: 1}
+}
+
 func SplitColorsAuto(frame v2btypes.Frame, colorCount int) (v2btypes.FrameLayers, error) {
+	return SplitColorsAutoWithOptions(frame, colorCount, DefaultSplitOptions())
+}
+
+// SplitColorsAutoWithOptions 自动量化调色板后按 opts 分层
+func SplitColorsAutoWithOptions(frame v2btypes.Frame, colorCount int, opts SplitOptions) (v2btypes.FrameLayers, error) {
+	if frame.Image == nil {
+		return v2btypes.FrameLayers{}, errors.New("nil image")
+	}
 	quantize := medianCutQuantize(frame.Image, colorCount)
-	return SplitColors(frame, quantize)
+	return SplitColorsWithOptions(frame, quantize, opts)
 }
 
 // SplitColors 将一帧拆分为颜色图层
 func SplitColors(frame v2btypes.Frame, rgb []color.RGBA) (v2btypes.FrameLayers, error) {
+	return SplitColorsWithOptions(frame, rgb, DefaultSplitOptions())
+}
+
+// SplitColorsWithOptions 将一帧拆分为颜色图层，面积不足 opts.MinArea 的图层被跳过
+func SplitColorsWithOptions(frame v2btypes.Frame, rgb []color.RGBA, opts SplitOptions) (v2btypes.FrameLayers, error) {
 	if frame.Image == nil {
 		return v2btypes.FrameLayers{}, errors.New("nil image")
 	}
@@ -117,21 +148,11 @@ func SplitColors(frame v2btypes.Frame, rgb []color.RGBA) (v2btypes.FrameLayers,
 	}
 
 	bounds := frame.Image.Bounds()
-	layers := make([]v2btypes.ColorLayer, len(rgb))
-	for i, hex := range rgb {
-		layers[i] = v2btypes.ColorLayer{
-			Color: hex,
-			Mask:  image.NewGray(bounds),
-		}
-		// 默认白色背景
-		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-			for x := bounds.Min.X; x < bounds.Max.X; x++ {
-				layers[i].Mask.Set(x, y, color.Gray{Y: 255})
-			}
-		}
-	}
+	w, h := bounds.Dx(), bounds.Dy()
 
-	// 遍历像素
+	// 先为每个像素找最近颜色并统计面积，再只为需要的图层分配掩码
+	nearest := make([]int, w*h)
+	areas := make([]int, len(rgb))
 	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 			r, g, b, _ := frame.Image.At(x, y).RGBA()
@@ -150,12 +171,42 @@ func SplitColors(frame v2btypes.Frame, rgb []color.RGBA) (v2btypes.FrameLayers,
 					bestIdx = i
 				}
 			}
-			// 在目标图层上标记黑色
-			layers[bestIdx].Mask.Set(x, y, color.Gray{Y: 0})
+			nearest[(y-bounds.Min.Y)*w+(x-bounds.Min.X)] = bestIdx
+			areas[bestIdx]++
 		}
 	}
 
-	return v2btypes.FrameLayers{Index: frame.Index, Layers: layers}, nil
+	result := v2btypes.FrameLayers{Index: frame.Index, Width: w, Height: h}
+	slot := make([]int, len(rgb)) // 调色板序号 -> 图层下标，-1 表示已跳过
+	for i, hex := range rgb {
+		if areas[i] == 0 || areas[i] < opts.MinArea {
+			slot[i] = -1
+			result.Skipped++
+			continue
+		}
+		mask := image.NewGray(bounds)
+		// 默认白色背景
+		for k := range mask.Pix {
+			mask.Pix[k] = 255
+		}
+		slot[i] = len(result.Layers)
+		result.Layers = append(result.Layers, v2btypes.ColorLayer{
+			Color:        hex,
+			Mask:         mask,
+			PaletteIndex: i,
+			Area:         areas[i],
+		})
+	}
+
+	// 在目标图层上标记黑色
+	for k, idx := range nearest {
+		if li := slot[idx]; li >= 0 {
+			m := result.Layers[li].Mask
+			m.Pix[(k/w)*m.Stride+k%w] = 0
+		}
+	}
+
+	return result, nil
 }
 
 // SplitAllFrames 对多帧进行颜色分层（并行版）
@@ -241,6 +292,11 @@ func SplitAllFramesAuto(frames []v2btypes.Frame, colorCount int, parallel int) (
 
 // SplitAllFramesAutoWithProgress 支持进度回调
 func SplitAllFramesAutoWithProgress(frames []v2btypes.Frame, colorCount int, parallel int, progress func()) ([]v2btypes.FrameLayers, error) {
+	return SplitAllFramesAutoWithOptions(frames, colorCount, parallel, DefaultSplitOptions(), progress)
+}
+
+// SplitAllFramesAutoWithOptions 按 opts 分层，支持进度回调
+func SplitAllFramesAutoWithOptions(frames []v2btypes.Frame, colorCount int, parallel int, opts SplitOptions, progress func()) ([]v2btypes.FrameLayers, error) {
 	if len(frames) == 0 {
 		return nil, errors.New("no frames provided")
 	}
@@ -259,7 +315,7 @@ func SplitAllFramesAutoWithProgress(frames []v2btypes.Frame, colorCount int, par
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
-			layers, err := SplitColorsAuto(frame, colorCount)
+			layers, err := SplitColorsAutoWithOptions(frame, colorCount, opts)
 			if err != nil {
 				errs <- err
 				return
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"video2bas/bas2xml"
+	"video2bas/emit"
+	"video2bas/json2ass"
+	"video2bas/json2bas"
+	"video2bas/json2lottie"
+	"video2bas/json2svg"
+	"video2bas/svg2json"
+	v2btypes "video2bas/type"
+)
+
+// ensureOutputDir 检查 outputPath 的目录是否存在，不存在则创建
+func ensureOutputDir(outputPath string) {
+	if strings.Contains(outputPath, "/") {
+		dir := strings.TrimRight(outputPath, "/")
+		if dir != "" {
+			dir = dir[:strings.LastIndex(dir, "/")]
+			if dir != "" {
+				err := os.MkdirAll(dir, os.ModePerm)
+				log.Println("Output directory:", dir)
+				if err != nil {
+					log.Fatal(err)
+				}
+			}
+		}
+	}
+}
+
+// outputs 为一次转换的全部输出：-format 列出的格式写到 -output 前缀加扩展名，
+// 单独指定了路径的格式（如 -ass）写到该路径
+type outputs struct {
+	*emit.Multi
+	bas   *emit.BAS
+	delta bool
+	tween bool
+	files []*os.File
+	bufs  []*bufio.Writer
+}
+
+// outputPaths 返回各输出格式的路径，BAS 为分块文件前缀
+func (opts pipelineOptions) outputPaths() map[string]string {
+	ext := map[string]string{
+		emit.FormatBAS:    "",
+		emit.FormatXML:    ".xml",
+		emit.FormatSVG:    ".svg",
+		emit.FormatHTML:   ".html",
+		emit.FormatASS:    ".ass",
+		emit.FormatLottie: ".json",
+		emit.FormatJSONL:  ".jsonl",
+	}
+	paths := make(map[string]string)
+	for _, f := range opts.Formats {
+		paths[f] = opts.OutputPath + ext[f]
+	}
+	for f, p := range map[string]string{
+		emit.FormatXML:    opts.XMLOut,
+		emit.FormatSVG:    opts.SVGOut,
+		emit.FormatHTML:   opts.HTMLOut,
+		emit.FormatASS:    opts.ASSOut,
+		emit.FormatLottie: opts.LottieOut,
+		emit.FormatJSONL:  opts.FramesOut,
+	} {
+		if p != "" {
+			paths[f] = p
+		}
+	}
+	return paths
+}
+
+// generatorOptions 返回生成 BAS 代码的参数，viewBox 由第一帧补全
+func (opts pipelineOptions) generatorOptions() json2bas.Options {
+	return json2bas.Options{
+		Rate:      opts.FPS,
+		Path:      opts.Path,
+		KeepBlack: opts.KeepBlack,
+		Style:     opts.Style,
+		IDs:       opts.IDs,
+		Delta:     opts.Delta,
+		Tween:     opts.Tween,
+		Fit:       opts.Coords.Fit,
+		Player:    opts.Player,
+	}
+}
+
+func newOutputs(opts pipelineOptions) *outputs {
+	o := &outputs{Multi: emit.NewMulti(), delta: opts.Delta, tween: opts.Tween}
+	paths := opts.outputPaths()
+
+	// .bas.txt 分块的时间相对动画开始，手动投稿时发送时间均为 -start；
+	// XML 由另一组以各自第一帧为起点的分块生成，每条弹幕的发送时间为 -start 加上分块起点
+	if basPath, ok := paths[emit.FormatBAS]; ok {
+		ensureOutputDir(basPath)
+		o.bas = o.addBAS(opts, basPath, false, chunkLogger{})
+	}
+	if xmlPath, ok := paths[emit.FormatXML]; ok {
+		xmlOpts := opts.XML
+		xmlOpts.SendTime = opts.StartTime / 1000
+		xml := emit.NewXML(o.create(xmlPath), xmlOpts)
+		handlers := []emit.ChunkHandler{xml}
+		if o.bas == nil {
+			handlers = append(handlers, chunkLogger{})
+		}
+		bas := o.addBAS(opts, "", true, handlers...)
+		if o.bas == nil {
+			o.bas = bas
+		}
+		o.Add(xml)
+	}
+	if path, ok := paths[emit.FormatJSONL]; ok {
+		enc := svg2json.NewFrameEncoder(o.create(path), opts.Path)
+		o.Add(emit.Frames(enc.Encode, nil))
+	}
+	if path, ok := paths[emit.FormatASS]; ok {
+		assOpts := opts.ASS
+		assOpts.Rate = opts.FPS
+		assOpts.KeepBlack = opts.KeepBlack
+		assOpts.Fit = opts.Coords.Fit
+		assOpts.Style = opts.Style
+		assOpts.StartTime = opts.StartTime
+		ass := json2ass.NewWriter(o.create(path), assOpts)
+		o.Add(emit.Frames(ass.WriteFrame, ass.Close))
+	}
+	svgOpts := json2svg.DefaultOptions()
+	svgOpts.Rate = opts.FPS
+	svgOpts.Path = opts.Path
+	svgOpts.KeepBlack = opts.KeepBlack
+	svgOpts.Fit = opts.Coords.Fit
+	if path, ok := paths[emit.FormatSVG]; ok {
+		svg := json2svg.NewWriter(o.create(path), svgOpts)
+		o.Add(emit.Frames(svg.WriteFrame, svg.Close))
+	}
+	if path, ok := paths[emit.FormatHTML]; ok {
+		page := json2svg.NewHTMLWriter(o.create(path), svgOpts, videoSrc(path, opts.VideoPath))
+		o.Add(emit.Frames(page.WriteFrame, page.Close))
+	}
+	if path, ok := paths[emit.FormatLottie]; ok {
+		lottieOpts := json2lottie.DefaultOptions()
+		lottieOpts.Rate = opts.FPS
+		lottieOpts.Precision = opts.Path.Precision
+		lottieOpts.KeepBlack = opts.KeepBlack
+		lottie := json2lottie.NewWriter(o.create(path), lottieOpts)
+		o.Add(emit.Frames(lottie.WriteFrame, lottie.Close))
+	}
+	return o
+}
+
+// addBAS 加入一个 BAS 输出，path 为空时只生成分块交给 handlers，不写文件
+func (o *outputs) addBAS(opts pipelineOptions, path string, rebase bool, handlers ...emit.ChunkHandler) *emit.BAS {
+	bas, err := emit.NewBAS(emit.BASOptions{
+		OutputPath: path,
+		MaxSize:    opts.MaxFileSize,
+		Strategy:   opts.Strategy,
+		Parallel:   opts.Parallel,
+		Rebase:     rebase,
+		Generator:  opts.generatorOptions(),
+	}, handlers...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	o.Add(bas)
+	return bas
+}
+
+func (o *outputs) create(path string) io.Writer {
+	ensureOutputDir(path)
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	buf := bufio.NewWriter(file)
+	o.files = append(o.files, file)
+	o.bufs = append(o.bufs, buf)
+	return buf
+}
+
+// Frame 输出一帧
+func (o *outputs) Frame(fd v2btypes.FrameData) {
+	if err := o.Multi.Frame(fd); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Frames 输出全部帧
+func (o *outputs) Frames(data []v2btypes.FrameData) {
+	if err := o.Multi.Frames(data); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Close 结束全部输出，关闭文件并输出汇总
+func (o *outputs) Close() {
+	if err := o.End(); err != nil {
+		log.Fatal(err)
+	}
+	for i, f := range o.files {
+		if err := o.bufs[i].Flush(); err != nil {
+			log.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatal(err)
+		}
+		log.Println("Written", f.Name())
+	}
+	if o.bas != nil {
+		total := o.bas.Total()
+		log.Println("BAS chunks:", o.bas.Chunks())
+		log.Printf("Path data: %d bytes, saved %d bytes", total.PathBytes, total.Saved())
+		log.Printf("Layers: %d emitted, %d empty or negligible skipped", total.Layers, total.Skipped)
+		if o.delta {
+			log.Printf("Delta: %d unchanged layers reused", total.Reused)
+		}
+		if o.tween {
+			log.Printf("Tween: %d layers tweened from the previous frame", total.Tweened)
+		}
+	}
+}
+
+// chunkLogger 在每个 BAS 分块结束时输出其统计
+type chunkLogger struct{}
+
+func (chunkLogger) Chunk(c emit.Chunk) error {
+	log.Printf("Chunk %d: %d bytes, %d layers, path data %d bytes (saved %d bytes)",
+		c.Index, len(c.Code), c.Stats.Layers, c.Stats.PathBytes, c.Stats.Saved())
+	return nil
+}
+
+// xmlOptions 返回导出 XML 的默认参数，发送时间戳取当前时间
+func xmlOptions() bas2xml.Options {
+	opts := bas2xml.DefaultOptions()
+	opts.Timestamp = time.Now().Unix()
+	return opts
+}
+
+// xmlOnlyFormats 处理已弃用的 -xmlonly：去掉 bas 并确保输出 xml，等同于在 -format 中以 xml 代替 bas
+func xmlOnlyFormats(formats []string) []string {
+	out := []string{emit.FormatXML}
+	for _, f := range formats {
+		if f != emit.FormatBAS && f != emit.FormatXML {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// writeIDMap 在指定了 -idmap 时写出标识符映射文件
+func writeIDMap(opts pipelineOptions) {
+	if opts.IDMap == "" || opts.IDs == nil {
+		return
+	}
+	ensureOutputDir(opts.IDMap)
+	f, err := os.Create(opts.IDMap)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := opts.IDs.WriteMap(f); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Identifier map written to", opts.IDMap)
+}